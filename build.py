@@ -14,7 +14,7 @@ EXE = "launcher.exe"
 
 # list of files and dirs to include in output archive
 FILES = [EXE, "config.toml", "raylib.dll"]
-DIRS = ["Fonts"]
+DIRS = ["Fonts", "Themes"]
 
 
 def run(args: list[str]) -> str:
@@ -5,6 +5,7 @@ go 1.23.2
 require (
 	github.com/BurntSushi/toml v1.5.0
 	github.com/gen2brain/raylib-go/raylib v0.0.0-20250409052854-a4292f0f0412
+	golang.org/x/text v0.24.0
 )
 
 require (
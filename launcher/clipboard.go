@@ -0,0 +1,326 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	CLIPBOARD_POLL      = 1.0 // seconds between two readings of the clipboard
+	CLIPBOARD_TITLE_LEN = 60  // number of characters of an entry shown in the list
+)
+
+// Clipboard of the system, raylib_clipboard unless testing
+type Clipboard interface {
+	Get() string
+	Set(text string)
+}
+
+// The clipboard used by the snippets and the clipboard history
+var system_clipboard Clipboard = raylib_clipboard{}
+
+// The clipboard of raylib, it must be used by the GUI thread only
+type raylib_clipboard struct{}
+
+func (raylib_clipboard) Get() string {
+	return rl.GetClipboardText()
+}
+
+func (raylib_clipboard) Set(text string) {
+	rl.SetClipboardText(text)
+}
+
+// Settings of the clipboard history in the config file
+type ClipboardConfig struct {
+	Enabled    bool
+	Keyword    string   `toml:",omitempty"` // a query starting with it lists the history
+	File       string   `toml:",omitempty"` // where the history is saved
+	MaxEntries int      `toml:",omitempty"` // the oldest entries are removed
+	MaxLength  int      `toml:",omitempty"` // longer texts are not recorded, in bytes (0 for no limit)
+	Ignore     []string `toml:",omitempty"` // regexes of the texts not recorded (eg: passwords)
+}
+
+// An entry of the clipboard history
+type ClipboardEntry struct {
+	Text string
+	Time time.Time // last time it was copied
+}
+
+// ClipboardHistory records the texts copied while the launcher runs, or while
+// the clipboard daemon runs, the most recent first and without duplicates. It lists them for the queries
+// starting with its keyword, and selecting one copies it again.
+type ClipboardHistory struct {
+	keyword     string
+	file        string
+	max_entries int
+	max_length  int
+	ignore      []*regexp.Regexp
+	clipboard   Clipboard
+
+	lock    sync.Mutex // the entries are searched in the background
+	entries []ClipboardEntry
+	last    string // last text read from the clipboard
+}
+
+// Checks the settings, sets the default values and loads the history
+func NewClipboardHistory(config *ClipboardConfig) (*ClipboardHistory, error) {
+	if config.Keyword == "" {
+		config.Keyword = "cb"
+	}
+	if config.File == "" {
+		config.File = "clipboard.toml"
+	}
+	if config.MaxEntries == 0 {
+		config.MaxEntries = 100
+	}
+
+	if strings.Contains(config.Keyword, " ") {
+		return nil, fmt.Errorf("invalid clipboard keyword '%v', it must not contain spaces", config.Keyword)
+	}
+	if config.MaxEntries < 0 || config.MaxLength < 0 {
+		return nil, errors.New("invalid clipboard settings, MaxEntries and MaxLength must be positive")
+	}
+
+	history := &ClipboardHistory{
+		keyword:     config.Keyword,
+		file:        config.File,
+		max_entries: config.MaxEntries,
+		max_length:  config.MaxLength,
+		clipboard:   system_clipboard,
+	}
+
+	for _, pattern := range config.Ignore {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clipboard ignore pattern: %w", err)
+		}
+		history.ignore = append(history.ignore, regex)
+	}
+
+	history.load()
+
+	return history, nil
+}
+
+// Merges the saved entries into the history, they may have been recorded by
+// another instance (see ClipboardDaemon_Start). The ignored texts are
+// skipped, in case the patterns have changed since they were saved.
+// An invalid file is logged and ignored, the launcher must start anyway.
+func (h *ClipboardHistory) load() {
+	// there is no history the first time
+	var saved struct{ Entries []ClipboardEntry }
+	_, err := toml.DecodeFile(h.file, &saved)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("Invalid clipboard history, it is ignored:", err)
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, entry := range saved.Entries {
+		if h.accepts(entry.Text) {
+			h.add(entry.Text, entry.Time)
+		}
+	}
+	h.entries = h.entries[:min(len(h.entries), h.max_entries)]
+}
+
+// Records the text of the clipboard if it has changed, and saves the history
+func (h *ClipboardHistory) Poll() error {
+	text := h.clipboard.Get()
+	if text == h.last {
+		return nil
+	}
+	h.last = text
+
+	if !h.Add(text, time.Now()) {
+		return nil
+	}
+
+	return h.Save()
+}
+
+// Adds a text to the history, or moves it first if it is already there.
+// Returns false if the text is ignored.
+func (h *ClipboardHistory) Add(text string, now time.Time) bool {
+	if !h.accepts(text) {
+		return false
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.add(text, now)
+	h.entries = h.entries[:min(len(h.entries), h.max_entries)]
+
+	return true
+}
+
+// Returns false if the text must not be recorded: empty, too long or ignored
+func (h *ClipboardHistory) accepts(text string) bool {
+	if strings.TrimSpace(text) == "" || (h.max_length != 0 && len(text) > h.max_length) {
+		return false
+	}
+	for _, regex := range h.ignore {
+		if regex.MatchString(text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Adds the entry, keeping them sorted by time. h.lock must be held.
+func (h *ClipboardHistory) add(text string, time time.Time) {
+	for i, entry := range h.entries {
+		if entry.Text == text {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+
+	i := 0
+	for i < len(h.entries) && h.entries[i].Time.After(time) {
+		i++
+	}
+	h.entries = append(h.entries[:i], append([]ClipboardEntry{{Text: text, Time: time}}, h.entries[i:]...)...)
+}
+
+// Returns a copy of the entries, the most recent first
+func (h *ClipboardHistory) Entries() []ClipboardEntry {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return append([]ClipboardEntry(nil), h.entries...)
+}
+
+// Saves the history, with the entries saved by another instance since it was
+// loaded. The file is written next to the history then renamed, so that the
+// other instance never reads a half-written file.
+func (h *ClipboardHistory) Save() error {
+	h.load()
+
+	saved := struct{ Entries []ClipboardEntry }{h.Entries()}
+
+	file, err := os.CreateTemp(filepath.Dir(h.file), filepath.Base(h.file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // if it has not been renamed
+
+	err = toml.NewEncoder(file).Encode(saved)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), h.file)
+}
+
+func (h *ClipboardHistory) Name() string {
+	return "Clipboard"
+}
+
+// Lists the entries matching the rest of the query, all its characters must
+// be in the entry in the same order (eg: "hlo" finds "hello")
+func (h *ClipboardHistory) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	rest, ok := cut_keyword(query.Text, h.keyword)
+	if !ok {
+		return
+	}
+	pattern := fold_string(strings.ReplaceAll(rest, " ", ""))
+
+	var rules []*Rule
+	for _, entry := range h.Entries() {
+		if !fuzzy_match(fold_string(entry.Text), pattern) {
+			continue
+		}
+
+		text := entry.Text
+		rules = append(rules, &Rule{
+			Match:       clipboard_title(text),
+			Description: "copied " + entry.Time.Local().Format("2006-01-02 15:04"),
+			Icon:        "edit-paste",
+			execute:     func() { h.clipboard.Set(text) },
+			preview:     func() []string { return append([]string{"Copied to the clipboard:"}, strings.Split(text, "\n")...) },
+		})
+	}
+
+	SendResults(ctx, results, rules)
+}
+
+// Records the clipboard in the background, until the program is interrupted or
+// terminated: the launcher started with -clipboard at login. The history is
+// saved after each copy, and the launcher loads it when it opens. raylib needs
+// a window to read the clipboard, it stays hidden.
+func ClipboardDaemon_Start(config *Config) error {
+	if config.clipboard == nil {
+		return errors.New("the clipboard history is not enabled in the config")
+	}
+
+	rl.SetTraceLogLevel(rl.LogWarning)
+	rl.SetConfigFlags(rl.FlagWindowHidden)
+	rl.InitWindow(1, 1, APP_TITLE)
+	defer rl.CloseWindow()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(CLIPBOARD_POLL * time.Second)
+	defer ticker.Stop()
+
+	for {
+		// the events of the window are not used, but they must be handled
+		rl.PollInputEvents()
+		if err := config.clipboard.Poll(); err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Returns true if all the characters of the pattern are in the text, in the same order
+func fuzzy_match(text string, pattern string) bool {
+	for _, c := range pattern {
+		i := strings.IndexRune(text, c)
+		if i == -1 {
+			return false
+		}
+		text = text[i+len(string(c)):]
+	}
+
+	return true
+}
+
+// Returns the text on a single line, shortened to be displayed in the list
+func clipboard_title(text string) string {
+	title := strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(title); len(runes) > CLIPBOARD_TITLE_LEN {
+		title = string(runes[:CLIPBOARD_TITLE_LEN-1]) + "…"
+	}
+
+	return title
+}
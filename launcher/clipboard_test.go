@@ -0,0 +1,223 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Clipboard for the tests
+type test_clipboard struct {
+	text string
+}
+
+func (c *test_clipboard) Get() string {
+	return c.text
+}
+
+func (c *test_clipboard) Set(text string) {
+	c.text = text
+}
+
+func new_test_history(t *testing.T, config ClipboardConfig) (*ClipboardHistory, *test_clipboard) {
+	t.Helper()
+
+	config.Enabled = true
+	config.File = filepath.Join(t.TempDir(), "clipboard.toml")
+	history, err := NewClipboardHistory(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clipboard := &test_clipboard{}
+	history.clipboard = clipboard
+
+	return history, clipboard
+}
+
+func history_texts(history *ClipboardHistory) []string {
+	var texts []string
+	for _, entry := range history.Entries() {
+		texts = append(texts, entry.Text)
+	}
+	return texts
+}
+
+func TestClipboardHistoryAdd(t *testing.T) {
+	history, _ := new_test_history(t, ClipboardConfig{MaxEntries: 3, MaxLength: 10, Ignore: []string{`^sk-`}})
+	now := time.Now()
+
+	tests := []struct {
+		text  string
+		added bool
+		want  []string
+	}{
+		{"one", true, []string{"one"}},
+		{"two", true, []string{"two", "one"}},
+		{"one", true, []string{"one", "two"}}, // moved first, not duplicated
+		{"  ", false, []string{"one", "two"}},
+		{"sk-secret", false, []string{"one", "two"}},
+		{"much too long", false, []string{"one", "two"}},
+		{"three", true, []string{"three", "one", "two"}},
+		{"four", true, []string{"four", "three", "one"}}, // the oldest is removed
+	}
+
+	for i, test := range tests {
+		added := history.Add(test.text, now.Add(time.Duration(i)*time.Second))
+		if got := history_texts(history); added != test.added || !slices.Equal(got, test.want) {
+			t.Errorf("Add(%q) = %v, %v, want %v, %v", test.text, added, got, test.added, test.want)
+		}
+	}
+}
+
+func TestClipboardHistoryPollAndLoad(t *testing.T) {
+	history, clipboard := new_test_history(t, ClipboardConfig{})
+
+	clipboard.text = "first"
+	history.Poll()
+	clipboard.text = "second"
+	history.Poll()
+	history.Poll() // not changed
+
+	config := ClipboardConfig{File: history.file}
+	loaded, err := NewClipboardHistory(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history_texts(loaded); !slices.Equal(got, []string{"second", "first"}) {
+		t.Errorf("loaded history = %v", got)
+	}
+}
+
+func TestClipboardHistoryLoadIgnored(t *testing.T) {
+	history, _ := new_test_history(t, ClipboardConfig{})
+	history.Add("sk-secret", time.Now())
+	history.Add("public", time.Now().Add(time.Second))
+	history.Save()
+
+	// a pattern added later hides the entries already saved
+	config := ClipboardConfig{File: history.file, Ignore: []string{`^sk-`}}
+	loaded, err := NewClipboardHistory(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history_texts(loaded); !slices.Equal(got, []string{"public"}) {
+		t.Errorf("loaded history = %v, want the ignored entry removed", got)
+	}
+}
+
+func TestClipboardHistorySaveMerge(t *testing.T) {
+	launcher, _ := new_test_history(t, ClipboardConfig{})
+	now := time.Now()
+
+	// the daemon records a copy while the launcher is open
+	config := ClipboardConfig{File: launcher.file}
+	daemon, err := NewClipboardHistory(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon.Add("from the daemon", now)
+	daemon.Save()
+
+	launcher.Add("from the launcher", now.Add(time.Second))
+	launcher.Save()
+
+	want := []string{"from the launcher", "from the daemon"}
+	if got := history_texts(launcher); !slices.Equal(got, want) {
+		t.Errorf("history after Save = %v, want %v", got, want)
+	}
+
+	config = ClipboardConfig{File: launcher.file}
+	loaded, err := NewClipboardHistory(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history_texts(loaded); !slices.Equal(got, want) {
+		t.Errorf("saved history = %v, want %v", got, want)
+	}
+}
+
+func TestClipboardHistoryInvalidFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "clipboard.toml")
+	os.WriteFile(file, []byte("[[Entries]]\nText = \"half writ"), 0644)
+
+	// the launcher starts with an empty history
+	config := ClipboardConfig{File: file}
+	history, err := NewClipboardHistory(&config)
+	if err != nil || len(history.Entries()) != 0 {
+		t.Fatalf("NewClipboardHistory() = %v, %v, want an empty history", history, err)
+	}
+
+	// and the file is replaced by a valid one
+	history.Add("new", time.Now())
+	if err := history.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewClipboardHistory(&ClipboardConfig{File: file})
+	if err != nil || !slices.Equal(history_texts(loaded), []string{"new"}) {
+		t.Errorf("saved history = %v, %v", history_texts(loaded), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(file)); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want the history only", len(entries))
+	}
+}
+
+func TestClipboardHistorySearch(t *testing.T) {
+	history, clipboard := new_test_history(t, ClipboardConfig{})
+	history.Add("hello world", time.Now())
+	history.Add("Café au lait\nwith sugar", time.Now().Add(time.Second))
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"cb", []string{"Café au lait with sugar", "hello world"}},
+		{"CB hlo", []string{"hello world"}},
+		{"cb cafe", []string{"Café au lait with sugar"}},
+		{"cb xyz", nil},
+		{"cbhello", nil},
+		{"hello", nil},
+	}
+
+	for _, test := range tests {
+		results := make(chan []*Rule, 1)
+		history.Search(context.Background(), ParseQuery(test.input, ""), results)
+		close(results)
+
+		rules := <-results
+		if got := RulesToAray(rules); !slices.Equal(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.input, got, test.want)
+		}
+	}
+
+	// selecting an entry copies it
+	results := make(chan []*Rule, 1)
+	history.Search(context.Background(), ParseQuery("cb cafe", ""), results)
+	(<-results)[0].Execute()
+	if !strings.HasPrefix(clipboard.text, "Café au lait\n") {
+		t.Errorf("clipboard = %q after Execute", clipboard.text)
+	}
+}
+
+func TestClipboardTitle(t *testing.T) {
+	long := strings.Repeat("é", CLIPBOARD_TITLE_LEN+5)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"simple", "simple"},
+		{"  multi\n\tline  text ", "multi line text"},
+		{long, long[:2*(CLIPBOARD_TITLE_LEN-1)] + "…"},
+	}
+
+	for _, test := range tests {
+		if got := clipboard_title(test.text); got != test.want {
+			t.Errorf("clipboard_title(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
@@ -19,25 +19,59 @@ type Config struct {
 	Search struct {
 		SearchDescription bool
 		MaxResults        int32
+		Grouping          string         `toml:",omitempty"` // score (all results together) or section
+		SectionOrder      []string       `toml:",omitempty"` // order of the sections when grouping by section
+		SectionLimits     map[string]int `toml:",omitempty"` // maximum number of results of a section
+		RegexSigil        string         `toml:",omitempty"` // a query starting with it is a regex
 	}
 	UI struct {
-		TitleFontFile string
-		TitleFontSize int32
-		MainFontFile  string
-		MainFontSize  int32
+		TitleFontFile   string
+		TitleFontSize   int32
+		MainFontFile    string
+		MainFontSize    int32
+		FallbackFonts   []string `toml:",omitempty"` // fonts used for the characters missing in the main ones
+		Scale           float32  `toml:",omitempty"` // scale of the text, 0 to use the monitor DPI
+		ScrollMargin    int32    // number of rows kept visible around the selected one
+		WrapAround      bool     // going down from the last row selects the first one
+		ShowPreview     bool     // show the preview pane at start (it can be toggled)
+		ShowIcons       bool     // show the icons of the rules
+		IconTheme       string   `toml:",omitempty"` // freedesktop icon theme used for the icon names
+		PreviewPosition string   `toml:",omitempty"` // bottom or right
+		PreviewLines    int32    `toml:",omitempty"` // height of the preview pane at the bottom
+	}
+	Window struct {
+		Width           string `toml:",omitempty"` // pixels or percentage of the monitor width
+		Anchor          string `toml:",omitempty"` // center or top-third
+		Monitor         string `toml:",omitempty"` // primary, mouse or index of the monitor
+		ShrinkToResults bool   // the window height follows the number of displayed rows
 	}
 	Colors struct {
-		Main         string
-		Box          string
-		TextArea     string
-		FontActive   string
-		FontInactive string
-		FontMatch    string
-		RowEven      string
-		RowOdd       string
-		RowSelected  string
-	}
-	Rules []*Rule
+		Theme       string // built-in theme or file in the Themes directory
+		ThemeColors        // colors that replace the ones of the theme
+	}
+	Keys struct {
+		Preset   string
+		Bindings map[string][]string
+	}
+	Terminal  TerminalConfig
+	Clipboard ClipboardConfig
+	Symbols   SymbolsConfig
+	SSH       SSHConfig
+	Files     FilesConfig
+	Git       GitConfig
+	Processes ProcessesConfig
+	History   HistoryConfig
+	Rules     []*Rule
+	Scripts   []ScriptConfig       `toml:",omitempty"` // external programs giving results (see ScriptProvider)
+	Usage     map[string]RuleUsage `toml:",omitempty"` // use of the rules of the providers, updated by the launcher
+
+	keys    KeyBindings  // parsed from the Keys section
+	palette Palette      // parsed from the Colors section
+	window  WindowLayout // parsed from the Window section
+
+	providers []Provider        // search in the background while typing
+	clipboard *ClipboardHistory // nil if disabled
+	usage     *UsageStore       // updates Usage
 }
 
 func NewConfig(filepath string) (*Config, error) {
@@ -70,10 +104,32 @@ func NewConfig(filepath string) (*Config, error) {
 			log.Printf("Rule n°%v (%v) : %v\n", i, rule, err)
 		}
 	}
+	for i, err := range CheckAliases(config.Rules) {
+		if err != nil {
+			valid = false
+			log.Printf("Rule n°%v (%v) : %v\n", i, config.Rules[i], err)
+		}
+	}
 	if !valid {
 		return nil, errors.New("invalid rules detected")
 	}
 
+	// Check the key bindings, conflicts are not allowed
+	config.keys, err = NewKeyBindings(config.Keys.Preset, config.Keys.Bindings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key bindings: %w", err)
+	}
+
+	// Load the theme, the colors of the config file replace the ones of the theme
+	if config.Colors.Theme == "" {
+		config.Colors.Theme = "default"
+	}
+	theme, err := LoadTheme(config.Colors.Theme)
+	if err != nil {
+		return nil, err
+	}
+	config.palette = NewPalette(theme.Merge(config.Colors.ThemeColors))
+
 	// Loop on all rules. If the time was not defined, set it to epoch time 0
 	for _, rule := range config.Rules {
 		if rule.LastUse == undefined_time {
@@ -85,6 +141,12 @@ func NewConfig(filepath string) (*Config, error) {
 	if config.Search.MaxResults == 0 {
 		config.Search.MaxResults = 10
 	}
+	if config.Search.RegexSigil == "" {
+		config.Search.RegexSigil = "re:"
+	}
+	if config.Search.Grouping == "" {
+		config.Search.Grouping = GROUPING_SCORE
+	}
 	if config.UI.TitleFontFile == "" {
 		config.UI.TitleFontFile = "Fonts/CascadiaCode-SemiBold.ttf"
 	}
@@ -97,6 +159,103 @@ func NewConfig(filepath string) (*Config, error) {
 	if config.UI.MainFontSize == 0 {
 		config.UI.MainFontSize = 22
 	}
+	if config.UI.PreviewPosition == "" {
+		config.UI.PreviewPosition = PREVIEW_BOTTOM
+	}
+	if config.UI.PreviewLines == 0 {
+		config.UI.PreviewLines = 6
+	}
+	if config.UI.IconTheme == "" {
+		config.UI.IconTheme = ICON_THEME_FALLBACK
+	}
+	if config.Keys.Preset == "" {
+		config.Keys.Preset = "default"
+	}
+	if config.Window.Width == "" {
+		config.Window.Width = "600"
+	}
+	if config.Window.Anchor == "" {
+		config.Window.Anchor = ANCHOR_CENTER
+	}
+	if config.Window.Monitor == "" {
+		config.Window.Monitor = "primary"
+	}
+
+	if config.Search.Grouping != GROUPING_SCORE && config.Search.Grouping != GROUPING_SECTION {
+		return nil, fmt.Errorf("invalid grouping '%v', it must be %v or %v", config.Search.Grouping, GROUPING_SCORE, GROUPING_SECTION)
+	}
+	for section, limit := range config.Search.SectionLimits {
+		if limit < 0 {
+			return nil, fmt.Errorf("invalid limit %d for section '%v', it must be positive (or 0 for no limit)", limit, section)
+		}
+	}
+	if config.UI.PreviewPosition != PREVIEW_BOTTOM && config.UI.PreviewPosition != PREVIEW_RIGHT {
+		return nil, fmt.Errorf("invalid preview position '%v', it must be %v or %v", config.UI.PreviewPosition, PREVIEW_BOTTOM, PREVIEW_RIGHT)
+	}
+	if config.UI.Scale < 0 {
+		return nil, errors.New("invalid UI scale, it must be positive (or 0 to use the monitor DPI)")
+	}
+
+	// The terminal is used by the providers running command line programs
+	if err := config.Terminal.Check(); err != nil {
+		return nil, err
+	}
+	config.usage = NewUsageStore(&config.Usage)
+
+	// Load the clipboard history
+	if config.Clipboard.Enabled {
+		config.clipboard, err = NewClipboardHistory(&config.Clipboard)
+		if err != nil {
+			return nil, err
+		}
+		config.providers = append(config.providers, config.clipboard)
+	}
+
+	// The symbol picker updates the recent symbols of the config
+	if config.Symbols.Enabled {
+		config.providers = append(config.providers, NewSymbolPicker(&config.Symbols))
+	}
+
+	if config.SSH.Enabled {
+		config.providers = append(config.providers, NewSSHProvider(config.SSH, config.Terminal, config.usage))
+	}
+
+	config.providers = append(config.providers, NewFilesProviders(config.Files, config.usage)...)
+
+	// The repositories are searched when the first query is typed
+	if len(config.Git.Roots) != 0 {
+		git, err := NewGitProvider(&config.Git, config.Terminal, config.usage)
+		if err != nil {
+			return nil, err
+		}
+		config.providers = append(config.providers, git)
+	}
+
+	if config.Processes.Enabled {
+		config.providers = append(config.providers, NewProcessProvider(&config.Processes))
+	}
+
+	if config.History.Enabled {
+		history, err := NewHistoryProvider(&config.History, config.Terminal)
+		if err != nil {
+			return nil, err
+		}
+		config.providers = append(config.providers, history)
+	}
+
+	// Start the scripts when they are first needed
+	for _, script := range config.Scripts {
+		if err := script.Check(); err != nil {
+			return nil, err
+		}
+		config.providers = append(config.providers, NewScriptProvider(script))
+	}
+
+	// Check the window settings
+	config.window, err = NewWindowLayout(config.Window.Width, config.Window.Anchor, config.Window.Monitor, config.Window.ShrinkToResults)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window settings: %w", err)
+	}
 
 	return &config, nil
 }
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package launcher
+
+// Returns the position of the mouse cursor on the desktop.
+// It is only available on Windows, the window manager places the
+// window on the other systems.
+func cursor_position() (int, int, bool) {
+	return 0, 0, false
+}
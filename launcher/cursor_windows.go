@@ -0,0 +1,20 @@
+package launcher
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var get_cursor_pos = syscall.NewLazyDLL("user32.dll").NewProc("GetCursorPos")
+
+// Returns the position of the mouse cursor on the desktop
+func cursor_position() (int, int, bool) {
+	var point struct{ X, Y int32 }
+
+	result, _, _ := get_cursor_pos.Call(uintptr(unsafe.Pointer(&point)))
+	if result == 0 {
+		return 0, 0, false
+	}
+
+	return int(point.X), int(point.Y), true
+}
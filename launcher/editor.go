@@ -0,0 +1,332 @@
+package launcher
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Maximum number of states kept in the undo stack
+const EDITOR_MAX_UNDO = 100
+
+// LineEditor is the single line text editor used for the input field.
+// All positions are rune indexes in the text.
+// The selection is the range between the anchor and the cursor,
+// it is empty when both are equal.
+// The zero value is an empty editor ready to use.
+type LineEditor struct {
+	text   []rune
+	cursor int
+	anchor int
+
+	undo   []editorState
+	redo   []editorState
+	typing bool // true while typed characters are grouped in the same undo step
+}
+
+// Snapshot of the editor used by the undo/redo stacks
+type editorState struct {
+	text   []rune
+	cursor int
+	anchor int
+}
+
+func (e *LineEditor) String() string {
+	return string(e.text)
+}
+
+func (e *LineEditor) Len() int {
+	return len(e.text)
+}
+
+func (e *LineEditor) Cursor() int {
+	return e.cursor
+}
+
+// Returns the selection boundaries, start is always lower or equal to end
+func (e *LineEditor) Selection() (int, int) {
+	return min(e.cursor, e.anchor), max(e.cursor, e.anchor)
+}
+
+func (e *LineEditor) HasSelection() bool {
+	return e.cursor != e.anchor
+}
+
+func (e *LineEditor) SelectedText() string {
+	start, end := e.Selection()
+
+	return string(e.text[start:end])
+}
+
+// Replaces the whole text, the cursor is put at the end
+func (e *LineEditor) SetText(text string) {
+	e.save()
+	e.text = []rune(sanitize_line(text))
+	e.cursor = len(e.text)
+	e.anchor = e.cursor
+}
+
+// Inserts a typed character at the cursor (replacing the selection).
+// Consecutive typed characters are undone in one step.
+func (e *LineEditor) Type(char rune) {
+	if !e.typing || e.HasSelection() {
+		e.save()
+	}
+	e.replace_selection([]rune{char})
+
+	// save() resets the flag, so it has to be set afterwards
+	e.typing = true
+}
+
+// Inserts a string at the cursor (replacing the selection), eg: when pasting
+func (e *LineEditor) Insert(text string) {
+	if text == "" && !e.HasSelection() {
+		return
+	}
+
+	e.save()
+	e.replace_selection([]rune(sanitize_line(text)))
+}
+
+// Deletes the selection or the character before the cursor
+func (e *LineEditor) Backspace() {
+	if e.HasSelection() {
+		e.DeleteSelection()
+	} else if e.cursor > 0 {
+		e.save()
+		e.delete_range(e.cursor-1, e.cursor)
+	}
+}
+
+// Deletes the selection or the character after the cursor
+func (e *LineEditor) Delete() {
+	if e.HasSelection() {
+		e.DeleteSelection()
+	} else if e.cursor < len(e.text) {
+		e.save()
+		e.delete_range(e.cursor, e.cursor+1)
+	}
+}
+
+// Deletes the selection or the word before the cursor (eg: Ctrl+Backspace)
+func (e *LineEditor) DeleteWordLeft() {
+	if e.HasSelection() {
+		e.DeleteSelection()
+	} else if e.cursor > 0 {
+		e.save()
+		e.delete_range(e.word_left(), e.cursor)
+	}
+}
+
+// Deletes the selection or the word after the cursor (eg: Ctrl+Delete)
+func (e *LineEditor) DeleteWordRight() {
+	if e.HasSelection() {
+		e.DeleteSelection()
+	} else if e.cursor < len(e.text) {
+		e.save()
+		e.delete_range(e.cursor, e.word_right())
+	}
+}
+
+func (e *LineEditor) DeleteSelection() {
+	if !e.HasSelection() {
+		return
+	}
+
+	e.save()
+	e.replace_selection(nil)
+}
+
+// Returns the selected text and deletes it
+func (e *LineEditor) Cut() string {
+	text := e.SelectedText()
+	e.DeleteSelection()
+
+	return text
+}
+
+// Removes all the text
+func (e *LineEditor) Clear() {
+	if len(e.text) == 0 {
+		return
+	}
+
+	e.save()
+	e.text = nil
+	e.cursor = 0
+	e.anchor = 0
+}
+
+func (e *LineEditor) SelectAll() {
+	e.typing = false
+	e.anchor = 0
+	e.cursor = len(e.text)
+}
+
+// Moves the cursor to the given position.
+// If extend is true, the selection is extended up to the new position.
+func (e *LineEditor) SetCursor(pos int, extend bool) {
+	e.typing = false
+	e.cursor = max(0, min(pos, len(e.text)))
+
+	if !extend {
+		e.anchor = e.cursor
+	}
+}
+
+func (e *LineEditor) Left(extend bool) {
+	// without shift, a selection collapses to its start
+	if !extend && e.HasSelection() {
+		start, _ := e.Selection()
+		e.SetCursor(start, false)
+	} else {
+		e.SetCursor(e.cursor-1, extend)
+	}
+}
+
+func (e *LineEditor) Right(extend bool) {
+	// without shift, a selection collapses to its end
+	if !extend && e.HasSelection() {
+		_, end := e.Selection()
+		e.SetCursor(end, false)
+	} else {
+		e.SetCursor(e.cursor+1, extend)
+	}
+}
+
+func (e *LineEditor) WordLeft(extend bool) {
+	e.SetCursor(e.word_left(), extend)
+}
+
+func (e *LineEditor) WordRight(extend bool) {
+	e.SetCursor(e.word_right(), extend)
+}
+
+func (e *LineEditor) Home(extend bool) {
+	e.SetCursor(0, extend)
+}
+
+func (e *LineEditor) End(extend bool) {
+	e.SetCursor(len(e.text), extend)
+}
+
+// Restores the state before the last modification.
+// Returns false if there is nothing to undo.
+func (e *LineEditor) Undo() bool {
+	if len(e.undo) == 0 {
+		return false
+	}
+
+	e.redo = append(e.redo, e.state())
+	e.restore(e.undo[len(e.undo)-1])
+	e.undo = e.undo[:len(e.undo)-1]
+
+	return true
+}
+
+// Restores the state before the last undo.
+// Returns false if there is nothing to redo.
+func (e *LineEditor) Redo() bool {
+	if len(e.redo) == 0 {
+		return false
+	}
+
+	e.undo = append(e.undo, e.state())
+	e.restore(e.redo[len(e.redo)-1])
+	e.redo = e.redo[:len(e.redo)-1]
+
+	return true
+}
+
+// Pushes the current state in the undo stack before a modification
+func (e *LineEditor) save() {
+	e.typing = false
+	e.redo = nil
+	e.undo = append(e.undo, e.state())
+
+	// forget the oldest states
+	if len(e.undo) > EDITOR_MAX_UNDO {
+		e.undo = e.undo[len(e.undo)-EDITOR_MAX_UNDO:]
+	}
+}
+
+func (e *LineEditor) state() editorState {
+	return editorState{
+		text:   append([]rune(nil), e.text...),
+		cursor: e.cursor,
+		anchor: e.anchor,
+	}
+}
+
+func (e *LineEditor) restore(state editorState) {
+	e.typing = false
+	e.text = state.text
+	e.cursor = state.cursor
+	e.anchor = state.anchor
+}
+
+// Replaces the selection (that may be empty) with the given runes
+// and puts the cursor after them
+func (e *LineEditor) replace_selection(runes []rune) {
+	start, end := e.Selection()
+
+	text := make([]rune, 0, len(e.text)-(end-start)+len(runes))
+	text = append(text, e.text[:start]...)
+	text = append(text, runes...)
+	text = append(text, e.text[end:]...)
+
+	e.text = text
+	e.cursor = start + len(runes)
+	e.anchor = e.cursor
+}
+
+func (e *LineEditor) delete_range(start int, end int) {
+	e.anchor = start
+	e.cursor = end
+	e.replace_selection(nil)
+}
+
+// Returns the position of the start of the word before the cursor.
+// Like Ctrl+Backspace used to do, spaces before the cursor are skipped,
+// then everything up to the previous space.
+func (e *LineEditor) word_left() int {
+	pos := e.cursor
+
+	for pos > 0 && unicode.IsSpace(e.text[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(e.text[pos-1]) {
+		pos--
+	}
+
+	return pos
+}
+
+// Returns the position of the start of the word after the cursor
+func (e *LineEditor) word_right() int {
+	pos := e.cursor
+
+	for pos < len(e.text) && !unicode.IsSpace(e.text[pos]) {
+		pos++
+	}
+	for pos < len(e.text) && unicode.IsSpace(e.text[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+// The input is a single line, so line breaks and tabs
+// (eg: from the clipboard) are replaced by spaces
+func sanitize_line(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", " ")
+
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,224 @@
+package launcher
+
+import (
+	"testing"
+)
+
+// Types a string in the editor, character by character
+func type_string(e *LineEditor, text string) {
+	for _, char := range text {
+		e.Type(char)
+	}
+}
+
+// Checks the text, cursor and selection of the editor
+func check_editor(t *testing.T, e *LineEditor, text string, cursor int, sel_start int, sel_end int) {
+	t.Helper()
+
+	if e.String() != text {
+		t.Errorf("got text '%v', want '%v'", e.String(), text)
+	}
+	if e.Cursor() != cursor {
+		t.Errorf("got cursor %d, want %d", e.Cursor(), cursor)
+	}
+	if start, end := e.Selection(); start != sel_start || end != sel_end {
+		t.Errorf("got selection [%d, %d], want [%d, %d]", start, end, sel_start, sel_end)
+	}
+}
+
+func TestEditorTyping(t *testing.T) {
+	var e LineEditor
+
+	type_string(&e, "héllo")
+	check_editor(t, &e, "héllo", 5, 5, 5)
+
+	e.Left(false)
+	e.Left(false)
+	e.Type('X')
+	check_editor(t, &e, "hélXlo", 4, 4, 4)
+
+	e.Backspace()
+	e.Delete()
+	check_editor(t, &e, "hélo", 3, 3, 3)
+
+	// nothing to delete at the edges
+	e.Home(false)
+	e.Backspace()
+	e.End(false)
+	e.Delete()
+	check_editor(t, &e, "hélo", 4, 4, 4)
+
+	// the cursor can not go out of the text
+	e.Right(false)
+	check_editor(t, &e, "hélo", 4, 4, 4)
+	e.SetCursor(-3, false)
+	check_editor(t, &e, "hélo", 0, 0, 0)
+}
+
+func TestEditorWords(t *testing.T) {
+	var tests = []struct {
+		name   string
+		text   string
+		cursor int
+		action func(e *LineEditor)
+		want   string
+		want_c int
+	}{
+		{"word left 1", "open git  hub", 13, func(e *LineEditor) { e.WordLeft(false) }, "open git  hub", 10},
+		{"word left 2", "open git  hub", 10, func(e *LineEditor) { e.WordLeft(false) }, "open git  hub", 5},
+		{"word left 3", "open git  hub", 2, func(e *LineEditor) { e.WordLeft(false) }, "open git  hub", 0},
+		{"word right 1", "open git  hub", 0, func(e *LineEditor) { e.WordRight(false) }, "open git  hub", 5},
+		{"word right 2", "open git  hub", 5, func(e *LineEditor) { e.WordRight(false) }, "open git  hub", 10},
+		{"word right 3", "open git  hub", 11, func(e *LineEditor) { e.WordRight(false) }, "open git  hub", 13},
+		{"delete word left 1", "open git ", 9, func(e *LineEditor) { e.DeleteWordLeft() }, "open ", 5},
+		{"delete word left 2", "open", 4, func(e *LineEditor) { e.DeleteWordLeft() }, "", 0},
+		{"delete word left 3", "open git", 6, func(e *LineEditor) { e.DeleteWordLeft() }, "open it", 5},
+		{"delete word right 1", "open git", 0, func(e *LineEditor) { e.DeleteWordRight() }, "git", 0},
+		{"delete word right 2", "open git", 5, func(e *LineEditor) { e.DeleteWordRight() }, "open ", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e LineEditor
+
+			e.SetText(tt.text)
+			e.SetCursor(tt.cursor, false)
+			tt.action(&e)
+
+			check_editor(t, &e, tt.want, tt.want_c, tt.want_c, tt.want_c)
+		})
+	}
+}
+
+func TestEditorSelection(t *testing.T) {
+	var e LineEditor
+
+	e.SetText("hello world")
+
+	// shift + left selects backwards
+	e.WordLeft(true)
+	check_editor(t, &e, "hello world", 6, 6, 11)
+	if e.SelectedText() != "world" {
+		t.Errorf("got selected '%v', want 'world'", e.SelectedText())
+	}
+
+	// moving without shift collapses the selection
+	e.Left(false)
+	check_editor(t, &e, "hello world", 6, 6, 6)
+
+	// typing replaces the selection
+	e.End(true)
+	e.Type('!')
+	check_editor(t, &e, "hello !", 7, 7, 7)
+
+	// backspace and delete remove the selection only
+	e.Home(false)
+	e.Right(true)
+	e.Right(true)
+	e.Delete()
+	check_editor(t, &e, "llo !", 0, 0, 0)
+	e.Right(true)
+	e.Backspace()
+	check_editor(t, &e, "lo !", 0, 0, 0)
+
+	e.SelectAll()
+	check_editor(t, &e, "lo !", 4, 0, 4)
+	e.Right(false)
+	check_editor(t, &e, "lo !", 4, 4, 4)
+}
+
+func TestEditorClipboard(t *testing.T) {
+	var e LineEditor
+
+	e.SetText("copy paste")
+	e.Home(false)
+	e.WordRight(true)
+
+	if text := e.Cut(); text != "copy " {
+		t.Errorf("got cut '%v', want 'copy '", text)
+	}
+	check_editor(t, &e, "paste", 0, 0, 0)
+
+	e.End(false)
+	e.Insert(" copy ")
+	check_editor(t, &e, "paste copy ", 11, 11, 11)
+
+	// the input is a single line
+	e.Insert("a\r\nb\tc\nd")
+	check_editor(t, &e, "paste copy a b c d", 18, 18, 18)
+
+	// pasting replaces the selection
+	e.SelectAll()
+	e.Insert("new")
+	check_editor(t, &e, "new", 3, 3, 3)
+}
+
+func TestEditorUndoRedo(t *testing.T) {
+	var e LineEditor
+
+	if e.Undo() || e.Redo() {
+		t.Error("nothing should be undone/redone on an empty editor")
+	}
+
+	// typed characters are grouped
+	type_string(&e, "hello")
+	e.Type(' ')
+	e.Left(false)
+	e.Right(false)
+	type_string(&e, "world")
+	check_editor(t, &e, "hello world", 11, 11, 11)
+
+	e.Undo()
+	check_editor(t, &e, "hello ", 6, 6, 6)
+	e.Undo()
+	check_editor(t, &e, "", 0, 0, 0)
+	if e.Undo() {
+		t.Error("there should be nothing left to undo")
+	}
+
+	e.Redo()
+	e.Redo()
+	check_editor(t, &e, "hello world", 11, 11, 11)
+	if e.Redo() {
+		t.Error("there should be nothing left to redo")
+	}
+
+	// each deletion is a step, and the selection is restored
+	e.WordLeft(true)
+	e.Backspace()
+	e.Backspace()
+	check_editor(t, &e, "hello", 5, 5, 5)
+	e.Undo()
+	check_editor(t, &e, "hello ", 6, 6, 6)
+	e.Undo()
+	check_editor(t, &e, "hello world", 6, 6, 11)
+
+	// a new modification clears the redo stack
+	e.Insert("you")
+	if e.Redo() {
+		t.Error("redo stack should have been cleared")
+	}
+	check_editor(t, &e, "hello you", 9, 9, 9)
+
+	e.Clear()
+	check_editor(t, &e, "", 0, 0, 0)
+	e.Undo()
+	check_editor(t, &e, "hello you", 9, 9, 9)
+}
+
+func TestEditorUndoLimit(t *testing.T) {
+	var e LineEditor
+
+	for i := 0; i < EDITOR_MAX_UNDO+10; i++ {
+		e.Insert("x")
+	}
+
+	count := 0
+	for e.Undo() {
+		count++
+	}
+
+	if count != EDITOR_MAX_UNDO {
+		t.Errorf("got %d undo steps, want %d", count, EDITOR_MAX_UNDO)
+	}
+}
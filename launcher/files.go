@@ -0,0 +1,295 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings of the recent files and bookmarks providers in the config file
+type FilesConfig struct {
+	Recent        bool     // recently used files of the freedesktop desktops
+	RecentFile    string   `toml:",omitempty"` // ~/.local/share/recently-used.xbel if empty
+	Bookmarks     bool     // GTK bookmarks and the bookmark files
+	GTKBookmarks  string   `toml:",omitempty"` // ~/.config/gtk-3.0/bookmarks if empty
+	BookmarkFiles []string `toml:",omitempty"` // text files, a path per line
+	Opener        string   `toml:",omitempty"` // program opening the files, the default of the system if empty
+}
+
+// A file of a list
+type file_entry struct {
+	path string
+	name string    // displayed name, the file name if empty
+	time time.Time // last use, zero if unknown
+}
+
+// The entries of a file (eg: a list of files), read again when it is modified
+type cached_file[T any] struct {
+	path  string
+	parse func(path string) []T
+
+	lock    sync.Mutex
+	modtime time.Time
+	entries []T
+}
+
+// FilesProvider lists files and directories read from several lists (eg: the
+// recently used files), the ones that do not exist are hidden. The file name is
+// displayed and searched, with its full path as description.
+type FilesProvider struct {
+	name   string
+	lists  []*cached_file[file_entry]
+	opener string
+	usage  *UsageStore
+}
+
+// Returns the providers of the recent files and of the bookmarks, if enabled
+func NewFilesProviders(config FilesConfig, usage *UsageStore) []Provider {
+	var providers []Provider
+
+	opener := config.Opener
+	if opener == "" {
+		opener = default_opener()
+	}
+
+	if config.Recent {
+		path := config.RecentFile
+		if path == "" {
+			path = "~/.local/share/recently-used.xbel"
+		}
+
+		providers = append(providers, &FilesProvider{
+			name:   "Recent",
+			lists:  []*cached_file[file_entry]{{path: expand_home(path), parse: parse_xbel}},
+			opener: opener,
+			usage:  usage,
+		})
+	}
+
+	if config.Bookmarks {
+		path := config.GTKBookmarks
+		if path == "" {
+			path = "~/.config/gtk-3.0/bookmarks"
+		}
+
+		lists := []*cached_file[file_entry]{{path: expand_home(path), parse: parse_gtk_bookmarks}}
+		for _, file := range config.BookmarkFiles {
+			lists = append(lists, &cached_file[file_entry]{path: expand_home(file), parse: parse_bookmark_file})
+		}
+
+		providers = append(providers, &FilesProvider{
+			name:   "Bookmarks",
+			lists:  lists,
+			opener: opener,
+			usage:  usage,
+		})
+	}
+
+	return providers
+}
+
+// Returns the program opening a file with the default application
+func default_opener() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "explorer.exe"
+	case "darwin":
+		return "open"
+	default:
+		return "xdg-open"
+	}
+}
+
+func (p *FilesProvider) Name() string {
+	return p.name
+}
+
+func (p *FilesProvider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	var rules []*Rule
+	seen := map[string]bool{}
+
+	for _, list := range p.lists {
+		for _, entry := range list.get() {
+			if seen[entry.path] {
+				continue
+			}
+			seen[entry.path] = true
+
+			rules = append(rules, p.rule(entry))
+		}
+	}
+
+	// only the matching files are checked, there can be many
+	var found []*Rule
+	for _, rule := range query.Filter(rules, false) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		info, err := os.Stat(rule.Description)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			rule.Icon = "folder"
+		}
+
+		found = append(found, rule)
+	}
+
+	SendResults(ctx, results, found)
+}
+
+// Returns the rule opening the file
+func (p *FilesProvider) rule(entry file_entry) *Rule {
+	path := entry.path
+
+	rule := &Rule{
+		Match:       entry.name,
+		Description: path,
+		Exe:         p.opener,
+		Args:        []string{path},
+		Icon:        "file",
+		preview:     func() []string { return FilePreview(path, PREVIEW_MAX_LINES) },
+	}
+	if rule.Match == "" {
+		rule.Match = filepath.Base(path)
+	}
+
+	// the files used recently are sorted like the rules used recently
+	p.usage.Track(rule, p.name+":"+path)
+	if entry.time.After(rule.LastUse) {
+		rule.LastUse = entry.time
+	}
+
+	return rule
+}
+
+// Returns the entries of the file, reading it again if it has changed
+func (file *cached_file[T]) get() []T {
+	file.lock.Lock()
+	defer file.lock.Unlock()
+
+	info, err := os.Stat(file.path)
+	if err != nil {
+		file.entries = nil
+		return nil
+	}
+
+	if !info.ModTime().Equal(file.modtime) {
+		file.modtime = info.ModTime()
+		file.entries = file.parse(file.path)
+	}
+
+	return file.entries
+}
+
+// Returns the local files of a freedesktop recently-used.xbel file, the most recent first
+func parse_xbel(path string) []file_entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var xbel struct {
+		Bookmarks []struct {
+			Href     string `xml:"href,attr"`
+			Modified string `xml:"modified,attr"`
+			Visited  string `xml:"visited,attr"`
+		} `xml:"bookmark"`
+	}
+	if err := xml.Unmarshal(data, &xbel); err != nil {
+		return nil
+	}
+
+	var entries []file_entry
+	for _, bookmark := range xbel.Bookmarks {
+		file, ok := file_uri_path(bookmark.Href)
+		if !ok {
+			continue
+		}
+
+		entry := file_entry{path: file}
+		for _, value := range []string{bookmark.Modified, bookmark.Visited} {
+			if t, err := time.Parse(time.RFC3339, value); err == nil && t.After(entry.time) {
+				entry.time = t
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// Returns the local directories of a GTK bookmarks file: "file:///path optional label"
+func parse_gtk_bookmarks(path string) []file_entry {
+	var entries []file_entry
+
+	for _, line := range read_lines(path) {
+		uri, label, _ := strings.Cut(line, " ")
+		if file, ok := file_uri_path(uri); ok {
+			entries = append(entries, file_entry{path: file, name: strings.TrimSpace(label)})
+		}
+	}
+
+	return entries
+}
+
+// Returns the paths of a bookmark file: a path per line (~ is the home
+// directory), the empty lines and the ones starting with # are ignored
+func parse_bookmark_file(path string) []file_entry {
+	var entries []file_entry
+
+	for _, line := range read_lines(path) {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, file_entry{path: filepath.Clean(expand_home(line))})
+	}
+
+	return entries
+}
+
+// Returns the lines of a text file that are not empty, without the spaces around them
+func read_lines(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// Returns the path of a file:// URI, false if it is not a local file
+func file_uri_path(uri string) (string, bool) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" || (parsed.Host != "" && parsed.Host != "localhost") {
+		return "", false
+	}
+
+	path := parsed.Path
+	// file:///C:/Users on Windows
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+
+	return filepath.FromSlash(path), true
+}
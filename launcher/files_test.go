@@ -0,0 +1,105 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFilesProviders(t *testing.T) {
+	home := write_home(t, map[string]string{
+		"Documents/report.odt":     "report",
+		"Documents/notes.txt":      "first line\nsecond line",
+		"Projects/launcher/go.mod": "module launcher",
+		"my bookmarks.txt":         "# my folders\n~/Projects/launcher\n\n~/Missing\n",
+	})
+	doc := filepath.Join(home, "Documents")
+	uri := "file://" + filepath.ToSlash(home)
+
+	write_home_file := func(name string, content string) {
+		if err := os.WriteFile(filepath.Join(home, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.MkdirAll(filepath.Join(home, ".local", "share"), 0755)
+	os.MkdirAll(filepath.Join(home, ".config", "gtk-3.0"), 0755)
+	write_home_file(".local/share/recently-used.xbel", `<?xml version="1.0" encoding="UTF-8"?>
+<xbel version="1.0" xmlns:bookmark="http://www.freedesktop.org/standards/desktop-bookmarks">
+  <bookmark href="`+uri+`/Documents/report.odt" added="2025-01-02T10:00:00Z" modified="2025-01-02T10:00:00Z" visited="2025-01-03T10:00:00Z"/>
+  <bookmark href="`+uri+`/Documents/notes.txt" added="2025-01-01T10:00:00Z" modified="2025-01-01T10:00:00Z" visited="2025-01-01T10:00:00Z"/>
+  <bookmark href="`+uri+`/Documents/deleted.txt" added="2025-01-01T10:00:00Z" modified="2025-01-01T10:00:00Z" visited="2025-01-01T10:00:00Z"/>
+  <bookmark href="https://example.com/page" added="2025-01-01T10:00:00Z" modified="2025-01-01T10:00:00Z" visited="2025-01-01T10:00:00Z"/>
+</xbel>`)
+	write_home_file(".config/gtk-3.0/bookmarks", uri+"/Documents Docs\n"+uri+"/Projects\nsftp://server/home\n")
+
+	providers := NewFilesProviders(FilesConfig{
+		Recent:        true,
+		Bookmarks:     true,
+		BookmarkFiles: []string{"~/my bookmarks.txt"},
+		Opener:        "opener",
+	}, NewUsageStore(&map[string]RuleUsage{}))
+	if len(providers) != 2 {
+		t.Fatalf("%d providers, want 2", len(providers))
+	}
+
+	search := func(provider Provider, input string) []*Rule {
+		results := make(chan []*Rule, 1)
+		provider.Search(context.Background(), ParseQuery(input, ""), results)
+		close(results)
+		return <-results
+	}
+
+	// the files that do not exist are hidden
+	recent := search(providers[0], "")
+	if got := RulesToAray(recent); !slices.Equal(got, []string{"report.odt", "notes.txt"}) {
+		t.Errorf("recent files = %v", got)
+	}
+	if rule := recent[0]; rule.Description != filepath.Join(doc, "report.odt") || rule.Exe != "opener" || !slices.Equal(rule.Args, []string{rule.Description}) {
+		t.Errorf("rule = %+v", rule)
+	}
+	if want := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC); !recent[0].LastUse.Equal(want) {
+		t.Errorf("LastUse = %v, want the last visit %v", recent[0].LastUse, want)
+	}
+	if got := RulesToAray(search(providers[0], "note")); !slices.Equal(got, []string{"notes.txt"}) {
+		t.Errorf("recent files matching note = %v", got)
+	}
+
+	bookmarks := search(providers[1], "")
+	if got := RulesToAray(bookmarks); !slices.Equal(got, []string{"Docs", "Projects", "launcher"}) {
+		t.Errorf("bookmarks = %v", got)
+	}
+	if bookmarks[0].Icon != "folder" || bookmarks[0].Description != doc {
+		t.Errorf("bookmark: icon %q, path %q", bookmarks[0].Icon, bookmarks[0].Description)
+	}
+
+	// the list is read again when it changes
+	write_home_file("my bookmarks.txt", "~/Documents/notes.txt\n")
+	os.Chtimes(filepath.Join(home, "my bookmarks.txt"), time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+	if got := RulesToAray(search(providers[1], "notes")); !slices.Equal(got, []string{"notes.txt"}) {
+		t.Errorf("bookmarks after change = %v", got)
+	}
+}
+
+func TestFileURIPath(t *testing.T) {
+	tests := []struct {
+		uri  string
+		path string
+		ok   bool
+	}{
+		{"file:///home/user/My%20Documents/caf%C3%A9.txt", "/home/user/My Documents/café.txt", true},
+		{"file://localhost/tmp", "/tmp", true},
+		{"file://server/share", "", false},
+		{"https://example.com/", "", false},
+		{"sftp://server/home", "", false},
+	}
+
+	for _, test := range tests {
+		path, ok := file_uri_path(test.uri)
+		if ok != test.ok || (ok && path != filepath.FromSlash(test.path) && path != filepath.FromSlash(test.path[1:])) {
+			t.Errorf("file_uri_path(%q) = %q, %v, want %q, %v", test.uri, path, ok, test.path, test.ok)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package launcher
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Letters that are not decomposed by the normalization, or that fold to several letters
+var fold_special = map[rune]string{
+	'ß': "ss", 'ẞ': "ss",
+	'æ': "ae", 'Æ': "ae",
+	'œ': "oe", 'Œ': "oe",
+	'ø': "o", 'Ø': "o",
+	'ł': "l", 'Ł': "l",
+	'đ': "d", 'Đ': "d",
+	'ı': "i", // dotless i (Turkish), the dotted capital İ is decomposed
+}
+
+// folded_text is a text prepared for searching: without diacritics and case folded,
+// with the position in the original text of each of its bytes
+type folded_text struct {
+	source string   // original text
+	text   string   // folded text
+	spans  [][2]int // for each byte of text, the start and end of the original character (nil if they are the same)
+}
+
+// Folds the text: each character is decomposed (NFD), its combining marks are
+// removed and it is case folded. "École" and "ECOLE" both become "ecole".
+func fold_text(s string) folded_text {
+	if is_ascii(s) {
+		return folded_text{source: s, text: strings.ToLower(s)}
+	}
+
+	var result strings.Builder
+	var spans [][2]int
+
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		span := [2]int{i, i + size}
+		i += size
+
+		part := fold_rune(c)
+
+		// a character that disappears (a combining mark) is part of the previous one
+		if part == "" {
+			for j := len(spans) - 1; j >= 0 && spans[j][1] == span[0]; j-- {
+				spans[j][1] = span[1]
+			}
+			continue
+		}
+
+		result.WriteString(part)
+		for range len(part) {
+			spans = append(spans, span)
+		}
+	}
+
+	return folded_text{source: s, text: result.String(), spans: spans}
+}
+
+func is_ascii(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the folded text only
+func fold_string(s string) string {
+	return fold_text(s).text
+}
+
+// Returns the folded character, it can be several characters (ß gives ss) or none (combining mark)
+func fold_rune(c rune) string {
+	if c < utf8.RuneSelf {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		return string(c)
+	}
+
+	if special, ok := fold_special[c]; ok {
+		return special
+	}
+
+	var result strings.Builder
+	for _, d := range norm.NFD.String(string(c)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		if special, ok := fold_special[d]; ok {
+			result.WriteString(special)
+			continue
+		}
+
+		// upper then lower to fold the variants of a letter (eg: the final sigma)
+		result.WriteRune(unicode.ToLower(unicode.ToUpper(d)))
+	}
+
+	return result.String()
+}
+
+// Returns the position in the original text of the folded bytes [start, end), that can not be empty
+func (f *folded_text) original(start int, end int) (int, int) {
+	if f.spans == nil {
+		return start, end
+	}
+
+	return f.spans[start][0], f.spans[end-1][1]
+}
+
+// Returns the position in the original text of the first occurrence of the
+// folded term, -1, -1 if it is not found. With words, the term has to be at
+// the start of a word.
+func (f *folded_text) find(term string, words bool) (int, int) {
+	if term == "" {
+		return 0, 0
+	}
+
+	i := index_term(f.text, term, words)
+	if i == -1 {
+		return -1, -1
+	}
+
+	return f.original(i, i+len(term))
+}
+
+// Returns the index of the first occurrence of the term in the text, -1 if
+// it is not found. With words, the term has to be at the start of a word.
+func index_term(text string, term string, words bool) int {
+	if !words {
+		return strings.Index(text, term)
+	}
+
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], term)
+		if i == -1 {
+			break
+		}
+		i += start
+
+		previous, _ := utf8.DecodeLastRuneInString(text[:i])
+		if i == 0 || !is_word_rune(previous) {
+			return i
+		}
+
+		// the term starts with a complete character, the next match is after it
+		_, size := utf8.DecodeRuneInString(text[i:])
+		start = i + size
+	}
+
+	return -1
+}
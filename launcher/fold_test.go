@@ -0,0 +1,122 @@
+package launcher
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFoldText(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"Hello World", "hello world"},
+		{"École élémentaire", "ecole elementaire"},
+		{"Noël, garçon, œuvre", "noel, garcon, oeuvre"},
+		{"Straße ÜBER Größe", "strasse uber grosse"},
+		{"İstanbul, Işık, ĞÜŞÖÇ", "istanbul, isik, gusoc"},
+		{"école", "ecole"}, // already decomposed
+		{"ΟΔΥΣΣΕΥΣ οδυσσευς", "οδυσσευσ οδυσσευσ"},
+		{"日本語", "日本語"},
+	}
+
+	for _, tt := range tests {
+		if got := fold_string(tt.input); got != tt.want {
+			t.Errorf("'%v': got '%v', want '%v'", tt.input, got, tt.want)
+		}
+	}
+
+	// each folded byte gives the original character
+	folded := fold_text("aßé")
+	want := [][2]int{{0, 1}, {1, 3}, {1, 3}, {3, 5}}
+	if folded.text != "asse" || !reflect.DeepEqual(folded.spans, want) {
+		t.Errorf("got %v %v, want asse %v", folded.text, folded.spans, want)
+	}
+}
+
+func TestFindTermDiacritics(t *testing.T) {
+	var tests = []struct {
+		text  string
+		term  string
+		words bool
+		want  string // part of the text that is found, "-" if not found
+	}{
+		// French
+		{"École normale", "ecole", true, "École"},
+		{"Cahier d'école", "ECOLE", true, "école"},
+		{"Noël", "noel", true, "Noël"},
+		{"Garçon", "garc", true, "Garç"},
+		{"Œuvres complètes", "oeuvre", true, "Œuvre"},
+		{"école", "ec", true, "éc"},
+		{"Mes documents", "é", true, "-"},
+		// German
+		{"Straße", "strasse", true, "Straße"},
+		{"Straße", "stras", true, "Straß"},
+		{"Über uns", "uber", true, "Über"},
+		{"Größe", "GROSSE", false, "Größe"},
+		// Turkish
+		{"İstanbul", "istanbul", true, "İstanbul"},
+		{"Işık", "isik", true, "Işık"},
+		{"Yağmur çiçeği", "cicegi", true, "çiçeği"},
+		{"Yağmur çiçeği", "agmur", false, "ağmur"},
+		{"Yağmur çiçeği", "agmur", true, "-"},
+	}
+
+	for _, tt := range tests {
+		got := "-"
+		if start, end := find_term(tt.text, tt.term, tt.words); start != -1 {
+			got = tt.text[start:end]
+		}
+		if got != tt.want {
+			t.Errorf("'%v' in '%v': got '%v', want '%v'", tt.term, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRuleFilterDiacritics(t *testing.T) {
+	var rules = []*Rule{
+		{Match: "École", Description: "Dossier de l'école", Tags: []string{"Études"}, Exe: "explorer.exe"},
+		{Match: "Straßenbahn", Description: "Fahrplan öffnen", Exe: "firefox.exe"},
+		{Match: "İzmir", Description: "Hava durumu", Aliases: []string{"Işıklar"}, Exe: "firefox.exe"},
+	}
+
+	var tests = []struct {
+		input string
+		want  []string
+	}{
+		{"ecole", []string{"École"}},
+		{"#etudes", []string{"École"}},
+		{"strassen", []string{"Straßenbahn"}},
+		{"offnen", []string{"Straßenbahn"}},
+		{"izmir", []string{"İzmir"}},
+		{"isik", []string{"İzmir"}},
+		{"e", []string{"École", "Straßenbahn"}},
+	}
+
+	for _, tt := range tests {
+		ans := RulesToAray(FilterRules(rules, tt.input, true))
+		sort.Strings(ans)
+		sort.Strings(tt.want)
+		if !reflect.DeepEqual(ans, tt.want) {
+			t.Errorf("'%v': got %v, want %v", tt.input, ans, tt.want)
+		}
+	}
+
+	// the highlights are on the original characters
+	var display_tests = []struct {
+		rule  *Rule
+		input string
+		want  []string
+	}{
+		{rules[0], "eco", []string{"Éco", "le - Dossier de l'", "éco", "le"}},
+		{rules[1], "strass off", []string{"Straß", "enbahn - Fahrplan ", "öff", "nen"}},
+		{rules[2], "isi", []string{"", "İzmir (", "Işı", "klar) - Hava durumu"}},
+	}
+
+	for _, tt := range display_tests {
+		if ans := tt.rule.GetDisplayStrings(tt.input, true); !reflect.DeepEqual(ans, tt.want) {
+			t.Errorf("'%v': got %q, want %q", tt.input, ans, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package launcher
+
+import (
+	"image/color"
+	"log"
+	"os"
+	"sort"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Codepoints that are always loaded: ASCII, Latin-1 and Latin Extended-A
+var base_codepoints = [][2]rune{{0x20, 0x7E}, {0xA0, 0x17F}}
+
+// FontChain is a list of font files loaded with the same size.
+// Each character is drawn with the first font that contains it.
+type FontChain struct {
+	files      []string
+	size       int32
+	fonts      []rl.Font
+	codepoints map[rune]bool // codepoints requested when loading the fonts
+	glyph_font map[rune]int  // index of the font used for each codepoint
+}
+
+// Loads the fonts with the base codepoints and the ones of the texts.
+// The files that do not exist are ignored, the first one is the main font.
+func LoadFontChain(files []string, size int32, texts ...string) *FontChain {
+	fc := &FontChain{size: size, codepoints: map[rune]bool{}}
+
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			log.Printf("font %v: %v", file, err)
+			continue
+		}
+		fc.files = append(fc.files, file)
+	}
+
+	for _, limits := range base_codepoints {
+		for c := limits[0]; c <= limits[1]; c++ {
+			fc.codepoints[c] = true
+		}
+	}
+	add_codepoints(fc.codepoints, texts...)
+
+	fc.load()
+
+	return fc
+}
+
+func (fc *FontChain) Unload() {
+	for _, font := range fc.fonts {
+		rl.UnloadFont(font)
+	}
+	fc.fonts = nil
+}
+
+// Reloads the fonts if the texts contain codepoints that have not been loaded yet
+func (fc *FontChain) Require(texts ...string) {
+	if add_codepoints(fc.codepoints, texts...) {
+		fc.Unload()
+		fc.load()
+	}
+}
+
+// Returns the width of the text
+func (fc *FontChain) Measure(text string, size float32) float32 {
+	var width float32
+
+	for _, run := range split_font_runs(text, fc.font_index) {
+		width += rl.MeasureTextEx(fc.fonts[run.font], run.text, size, 0).X
+	}
+
+	return width
+}
+
+func (fc *FontChain) Draw(text string, position rl.Vector2, size float32, tint color.RGBA) {
+	for _, run := range split_font_runs(text, fc.font_index) {
+		font := fc.fonts[run.font]
+
+		rl.DrawTextEx(font, run.text, position, size, 0, tint)
+		position.X += rl.MeasureTextEx(font, run.text, size, 0).X
+	}
+}
+
+// Loads each font with the codepoints that are missing in the previous ones
+func (fc *FontChain) load() {
+	fc.glyph_font = map[rune]int{}
+
+	missing := make([]rune, 0, len(fc.codepoints))
+	for c := range fc.codepoints {
+		missing = append(missing, c)
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+
+	for _, file := range fc.files {
+		if len(missing) == 0 {
+			break
+		}
+
+		font := rl.LoadFontEx(file, fc.size, missing)
+		if !rl.IsFontValid(font) {
+			log.Printf("font %v: could not be loaded", file)
+			continue
+		}
+		fc.fonts = append(fc.fonts, font)
+
+		var still_missing []rune
+		for _, c := range missing {
+			// a missing glyph is loaded without advance (see LoadFontData in raylib)
+			info := rl.GetGlyphInfo(font, c)
+			if info.Value == c && info.AdvanceX > 0 {
+				fc.glyph_font[c] = len(fc.fonts) - 1
+			} else {
+				still_missing = append(still_missing, c)
+			}
+		}
+		missing = still_missing
+	}
+
+	// without font, use the raylib one
+	if len(fc.fonts) == 0 {
+		fc.fonts = append(fc.fonts, rl.GetFontDefault())
+	}
+}
+
+// Returns the index of the font to use for the codepoint,
+// the main font draws the ones that are not in any font
+func (fc *FontChain) font_index(c rune) int {
+	return fc.glyph_font[c]
+}
+
+// Adds the codepoints of the texts to the set, returns true if there are new ones
+func add_codepoints(set map[rune]bool, texts ...string) bool {
+	added := false
+
+	for _, text := range texts {
+		for _, c := range text {
+			if !set[c] && c >= 0x20 {
+				set[c] = true
+				added = true
+			}
+		}
+	}
+
+	return added
+}
+
+// A part of a text drawn with the same font
+type font_run struct {
+	text string
+	font int
+}
+
+// Splits a text in runs of characters that use the same font
+func split_font_runs(text string, font_of func(rune) int) []font_run {
+	var runs []font_run
+
+	start := 0
+	current := -1
+	for i, c := range text {
+		font := font_of(c)
+		if font != current && i > start {
+			runs = append(runs, font_run{text[start:i], current})
+			start = i
+		}
+		current = font
+	}
+
+	if start < len(text) {
+		runs = append(runs, font_run{text[start:], current})
+	}
+
+	return runs
+}
@@ -0,0 +1,60 @@
+package launcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddCodepoints(t *testing.T) {
+	set := map[rune]bool{'a': true, 'b': true}
+
+	if add_codepoints(set, "ab", "ba", "") {
+		t.Error("no codepoint should have been added")
+	}
+
+	if !add_codepoints(set, "abc", "日本") {
+		t.Error("codepoints should have been added")
+	}
+	for _, c := range "abc日本" {
+		if !set[c] {
+			t.Errorf("missing codepoint %q", c)
+		}
+	}
+
+	// control characters are never loaded
+	if add_codepoints(set, "\t\n") {
+		t.Error("control characters should be ignored")
+	}
+}
+
+func TestSplitFontRuns(t *testing.T) {
+	// the ASCII characters are in the main font, the others in the fallback one
+	font_of := func(c rune) int {
+		if c < 0x80 {
+			return 0
+		}
+		return 1
+	}
+
+	var tests = []struct {
+		text string
+		want []font_run
+	}{
+		{"", nil},
+		{"abc", []font_run{{"abc", 0}}},
+		{"日本", []font_run{{"日本", 1}}},
+		{"open 日本 file", []font_run{{"open ", 0}, {"日本", 1}, {" file", 0}}},
+		{"é", []font_run{{"é", 1}}},
+		{"aé", []font_run{{"a", 0}, {"é", 1}}},
+		{"éa", []font_run{{"é", 1}, {"a", 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := split_font_runs(tt.text, font_of)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Settings of the git repositories provider in the config file
+type GitConfig struct {
+	Roots     []string // directories searched for repositories
+	MaxDepth  int      `toml:",omitempty"` // number of directory levels searched under the roots, 3 if 0
+	Ignore    []string `toml:",omitempty"` // names of the directories not searched (patterns like "*.tmp" allowed)
+	Editor    string   `toml:",omitempty"` // program opening the repositories, VS Code if empty
+	Browser   string   `toml:",omitempty"` // program opening the remote URLs, the default of the system if empty
+	CacheFile string   `toml:",omitempty"` // where the list of repositories is saved between runs
+}
+
+// A git working tree
+type git_repo struct {
+	path   string
+	remote string // web URL of the origin remote (or the first one), "" if none
+}
+
+// GitProvider lists the git repositories found under the root directories. The
+// search runs in the background the first time a query is typed, meanwhile the
+// repositories found by the previous run are listed.
+type GitProvider struct {
+	roots      []string
+	max_depth  int
+	ignore     []string
+	editor     string
+	browser    string
+	cache_file string
+	terminal   TerminalConfig
+	usage      *UsageStore
+
+	once  sync.Once
+	done  chan struct{} // closed when the search of the repositories has finished
+	lock  sync.Mutex    // for repos
+	repos []git_repo
+}
+
+// Checks the settings, sets the default values and reads the cache
+func NewGitProvider(config *GitConfig, terminal TerminalConfig, usage *UsageStore) (*GitProvider, error) {
+	if config.MaxDepth == 0 {
+		config.MaxDepth = 3
+	}
+	if config.Ignore == nil {
+		config.Ignore = []string{"node_modules", "vendor", ".*"}
+	}
+	if config.Editor == "" {
+		config.Editor = "code"
+	}
+	if config.CacheFile == "" {
+		config.CacheFile = "repositories.txt"
+	}
+
+	if config.MaxDepth < 0 {
+		return nil, errors.New("invalid git settings, MaxDepth must be positive")
+	}
+	for _, pattern := range config.Ignore {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid git ignore pattern '%v': %w", pattern, err)
+		}
+	}
+
+	provider := &GitProvider{
+		max_depth:  config.MaxDepth,
+		ignore:     config.Ignore,
+		editor:     config.Editor,
+		browser:    config.Browser,
+		cache_file: config.CacheFile,
+		terminal:   terminal,
+		usage:      usage,
+		done:       make(chan struct{}),
+	}
+	if provider.browser == "" {
+		provider.browser = default_opener()
+	}
+	for _, root := range config.Roots {
+		provider.roots = append(provider.roots, expand_home(root))
+	}
+
+	// the repositories of the previous run
+	for _, path := range read_lines(provider.cache_file) {
+		provider.repos = append(provider.repos, git_repo{path: path, remote: git_remote_url(path)})
+	}
+
+	return provider, nil
+}
+
+func (p *GitProvider) Name() string {
+	return "Git"
+}
+
+// Sends the repositories known so far. While the search runs, the ones it
+// finds are sent in a second batch when it has finished.
+func (p *GitProvider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	p.once.Do(func() { go p.discover() })
+
+	searching := true
+	select {
+	case <-p.done:
+		searching = false
+	default:
+	}
+
+	known := p.repositories()
+	if !SendResults(ctx, results, p.rules(query, known)) || !searching {
+		return
+	}
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return
+	}
+
+	// the batches are added to each other, only the new ones are sent
+	var found []git_repo
+	for _, repo := range p.repositories() {
+		if !slices.ContainsFunc(known, func(r git_repo) bool { return r.path == repo.path }) {
+			found = append(found, repo)
+		}
+	}
+	if len(found) != 0 {
+		SendResults(ctx, results, p.rules(query, found))
+	}
+}
+
+// Returns the repositories known so far
+func (p *GitProvider) repositories() []git_repo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.repos
+}
+
+// Returns the rules of the repositories matching the query
+func (p *GitProvider) rules(query Query, repos []git_repo) []*Rule {
+	var rules []*Rule
+	for _, repo := range repos {
+		rules = append(rules, p.rule(repo))
+	}
+
+	return query.Filter(rules, false)
+}
+
+// Returns the rule opening the repository in the editor, with the other actions
+func (p *GitProvider) rule(repo git_repo) *Rule {
+	rule := &Rule{
+		Match:       filepath.Base(repo.path),
+		Description: repo.path,
+		Tags:        []string{"git"},
+		Exe:         p.editor,
+		Args:        []string{repo.path},
+		Icon:        "folder",
+		preview:     func() []string { return FilePreview(repo.path, PREVIEW_MAX_LINES) },
+	}
+	p.usage.Track(rule, "Git:"+repo.path)
+
+	terminal := &Rule{WorkDir: repo.path}
+	terminal.Exe, terminal.Args = p.terminal.Shell(repo.path)
+	rule.actions = append(rule.actions, Action{Name: "Open a terminal here", Run: terminal.Execute})
+
+	if repo.remote != "" {
+		browser := &Rule{Exe: p.browser, Args: []string{repo.remote}}
+		rule.actions = append(rule.actions, Action{Name: "Open " + repo.remote, Run: browser.Execute})
+	}
+
+	return rule
+}
+
+// Searches the repositories, and saves them for the next run
+func (p *GitProvider) discover() {
+	defer close(p.done)
+
+	var repos []git_repo
+	for _, root := range p.roots {
+		for _, path := range find_git_repos(root, p.max_depth, p.ignore) {
+			repos = append(repos, git_repo{path: path, remote: git_remote_url(path)})
+		}
+	}
+
+	p.lock.Lock()
+	p.repos = repos
+	p.lock.Unlock()
+
+	var paths strings.Builder
+	for _, repo := range repos {
+		paths.WriteString(repo.path + "\n")
+	}
+	if err := os.WriteFile(p.cache_file, []byte(paths.String()), 0644); err != nil {
+		log.Println("Could not save the git repositories:", err)
+	}
+}
+
+// Returns the git working trees under the root, up to max_depth levels below it.
+// The directories matching an ignore pattern and the repositories are not searched.
+func find_git_repos(root string, max_depth int, ignore []string) []string {
+	var repos []string
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		// .git is a directory, or a file in the worktrees and submodules
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			repos = append(repos, dir)
+			return
+		}
+		if depth >= max_depth {
+			return
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || slices.ContainsFunc(ignore, func(pattern string) bool {
+				match, _ := filepath.Match(pattern, entry.Name())
+				return match
+			}) {
+				continue
+			}
+			walk(filepath.Join(dir, entry.Name()), depth+1)
+		}
+	}
+	walk(filepath.Clean(root), 0)
+
+	return repos
+}
+
+// Returns the web URL of the origin remote of the repository (or of the first
+// one), "" if it has none
+func git_remote_url(repo string) string {
+	dir := git_common_dir(repo)
+	if dir == "" {
+		return ""
+	}
+
+	var remotes []string
+	var current string
+	urls := map[string]string{}
+
+	for _, line := range read_lines(filepath.Join(dir, "config")) {
+		if strings.HasPrefix(line, "[") {
+			current = ""
+			if name, ok := strings.CutPrefix(line, `[remote "`); ok {
+				current = strings.TrimSuffix(name, `"]`)
+				remotes = append(remotes, current)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if ok && current != "" && strings.TrimSpace(key) == "url" {
+			urls[current] = strings.TrimSpace(value)
+		}
+	}
+
+	if url, ok := urls["origin"]; ok {
+		return git_web_url(url)
+	}
+	for _, remote := range remotes {
+		if url, ok := urls[remote]; ok {
+			return git_web_url(url)
+		}
+	}
+
+	return ""
+}
+
+// Returns the git directory holding the config of the repository,
+// "" if it is not a repository
+func git_common_dir(repo string) string {
+	dir := filepath.Join(repo, ".git")
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return ""
+	}
+
+	// a worktree or submodule: "gitdir: path"
+	if !info.IsDir() {
+		data, err := os.ReadFile(dir)
+		if err != nil {
+			return ""
+		}
+		gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return ""
+		}
+		dir = strings.TrimSpace(gitdir)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(repo, dir)
+		}
+
+		// the worktrees share the config of the main repository
+		if common := read_lines(filepath.Join(dir, "commondir")); len(common) != 0 {
+			if filepath.IsAbs(common[0]) {
+				dir = common[0]
+			} else {
+				dir = filepath.Join(dir, common[0])
+			}
+		}
+	}
+
+	return dir
+}
+
+// Returns the web page of a remote URL,
+// git@github.com:user/repo.git and ssh://git@host:22/user/repo give https://host/user/repo.
+// Local remotes give "".
+func git_web_url(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+
+	if rest, ok := strings.CutPrefix(url, "ssh://"); ok {
+		host, path, _ := strings.Cut(rest, "/")
+		host = host[strings.LastIndex(host, "@")+1:]
+		host, _, _ = strings.Cut(host, ":")
+		return "https://" + host + "/" + path
+	}
+
+	// without the credentials
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+		if parsed, err := neturl.Parse(url); err == nil {
+			parsed.User = nil
+			return parsed.String()
+		}
+		return url
+	}
+	if rest, ok := strings.CutPrefix(url, "git://"); ok {
+		return "https://" + rest
+	}
+
+	// scp-like syntax: [user@]host:path
+	if host, path, ok := strings.Cut(url, ":"); ok && !strings.ContainsAny(host, `/\`) && len(host) > 1 {
+		host = host[strings.LastIndex(host, "@")+1:]
+		return "https://" + host + "/" + strings.TrimPrefix(path, "/")
+	}
+
+	return ""
+}
@@ -1,40 +1,46 @@
 package launcher
 
 import (
-	"errors"
 	"log"
-	"regexp"
-	"strconv"
-	"strings"
+	"slices"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 func GUI_Start(config *Config) {
 	const (
-		TARGET_FPS   = 60
-		WINDOW_WIDTH = 600
+		TARGET_FPS = 60
+
+		SCROLL_LINES      = 3   // number of rows scrolled by a mouse wheel step
+		DOUBLE_CLICK_TIME = 0.4 // max delay in seconds between the clicks of a double click
+		SEARCHING_TEXT    = "searching…"
+		PREVIEW_WIDTH     = 0.4 // part of the window width used by the preview on the right
 	)
 
 	var (
-		WINDOW_HEIGHT = config.UI.TitleFontSize +
-			2*config.UI.MainFontSize +
-			config.Search.MaxResults*config.UI.MainFontSize
+		// Window size, its height can change (see the Window section)
+		window_max_height int32
+		window_width      int32
+		window_height     int32
 
-		// Sizes
-		title_size = float32(config.UI.TitleFontSize)
-		main_size  = float32(config.UI.MainFontSize)
+		// Sizes, scaled by the monitor DPI
+		title_font_size int32
+		main_font_size  int32
+		title_size      float32
+		main_size       float32
 
 		// Colors
-		color_main          = parse_color("Main", &config.Colors.Main, "SkyBlue")
-		color_box           = parse_color("Box", &config.Colors.Box, "DarkGray")
-		color_text_area     = parse_color("TextArea", &config.Colors.TextArea, "RayWhite")
-		color_font_active   = parse_color("FontActive", &config.Colors.FontActive, "Black")
-		color_font_inactive = parse_color("FontInactive", &config.Colors.FontInactive, "Beige")
-		color_font_match    = parse_color("FontMatch", &config.Colors.FontMatch, "Red")
-		color_row_even      = parse_color("RowEven", &config.Colors.RowEven, "LightGray")
-		color_row_odd       = parse_color("RowOdd", &config.Colors.RowOdd, "Gray")
-		color_row_selected  = parse_color("RowSelected", &config.Colors.RowSelected, "Green")
+		color_main          = config.palette.Main
+		color_box           = config.palette.Box
+		color_text_area     = config.palette.TextArea
+		color_font_active   = config.palette.FontActive
+		color_font_inactive = config.palette.FontInactive
+		color_font_match    = config.palette.FontMatch
+		color_row_even      = config.palette.RowEven
+		color_row_odd       = config.palette.RowOdd
+		color_row_selected  = config.palette.RowSelected
+		color_header        = config.palette.Header
+		color_font_header   = config.palette.FontHeader
 
 		// Coordinates
 		coord_main  rl.Vector2
@@ -48,209 +54,510 @@ func GUI_Start(config *Config) {
 		tmp_text  string
 
 		// Fonts
-		font_text  rl.Font
-		font_title rl.Font
+		font_text  *FontChain
+		font_title *FontChain
+
+		// Icons of the rules, nil if they are not displayed
+		icons *IconCache
+
+		// Key bindings
+		keys = config.keys
 
 		// Elements
-		input_text         string = ""
-		rules_filtered     []*Rule
-		strings_filtered   [][]string
-		rules_needs_filter bool = true
-		active_element     int  = -1 // -1 = typing field, 0 to n = element in list
-		nb_rules           int
-		first_display_rule int
-		last_display_rule  int
+		input              LineEditor
+		caret_time         float64
+		index              = NewSearchIndex(config.Rules)
+		pipeline           = NewPipeline(config.providers)
+		query              Query
+		rules_static       []*Rule     // rules of the config file that match the query
+		rules_async        []*Rule     // rules given by the providers so far
+		rows_filtered      []ListRow   // rules and section headers
+		strings_filtered   *RowStrings // display strings of the rows, computed when they are displayed
+		rules_needs_filter bool        = true
+		rows_needs_update  bool
+
+		// Navigation in the list
+		list = NewViewport(int(config.Search.MaxResults), int(config.UI.ScrollMargin), config.UI.WrapAround)
+		view *Viewport // list or menu, the one that is displayed
+
+		// Mouse
+		mouse              rl.Vector2
+		hover_element      int = -1 // element under the mouse cursor, -1 if none
+		last_click_element int = -1
+		last_click_time    float64
+		is_selecting_text  bool // dragging the mouse in the input field
+		is_dragging_scroll bool // dragging the scroll bar
+		scroll_grab_offset float32
+
+		// Action menu, displayed instead of the list when not nil
+		menu_actions []Action
+		menu         = NewViewport(int(config.Search.MaxResults), 0, config.UI.WrapAround)
+
+		// Preview of the rule that would be executed
+		is_preview    = config.UI.ShowPreview
+		preview_rule  *Rule
+		preview_lines []string
 
 		// Misc.
-		is_running bool = true
+		is_running   bool = true
+		is_clicked   bool // a rule has been double clicked
+		is_scrollbar bool
+
+		// Clipboard history, the clipboard is read regularly while the launcher is open
+		clipboard_time float64 = -CLIPBOARD_POLL
 	)
 
+	// the section headers can not be selected
+	list.Disabled = func(element int) bool { return rows_filtered[element].Rule == nil }
+
 	// Only show warnings and above
 	rl.SetTraceLogLevel(rl.LogWarning)
 
-	// Set config and flags
+	// Set config and flags, the window is hidden until it is placed
 	//rl.SetConfigFlags(rl.FlagWindowTransparent)
+	rl.SetConfigFlags(rl.FlagWindowHidden)
 	rl.SetWindowState(rl.FlagWindowUndecorated)
 	rl.SetTargetFPS(TARGET_FPS)
 
-	// Create new window
-	rl.InitWindow(WINDOW_WIDTH, WINDOW_HEIGHT, APP_TITLE)
+	// Create new window, the monitors are only known after that
+	rl.InitWindow(int32(config.window.Width), 100, APP_TITLE)
 	defer rl.CloseWindow()
 
+	// Move the window on its monitor, to know the DPI of the monitor
+	monitor, has_monitor := select_monitor(config.window)
+	if has_monitor {
+		rl.SetWindowPosition(monitor.X, monitor.Y)
+	}
+
+	scale := config.UI.Scale
+	if scale == 0 {
+		scale = rl.GetWindowScaleDPI().Y
+	}
+
+	title_font_size = scale_size(config.UI.TitleFontSize, scale)
+	main_font_size = scale_size(config.UI.MainFontSize, scale)
+	title_size = float32(title_font_size)
+	main_size = float32(main_font_size)
+
+	window_max_height = title_font_size + (2+config.Search.MaxResults)*main_font_size
+	if config.UI.PreviewPosition == PREVIEW_BOTTOM {
+		window_max_height += config.UI.PreviewLines * main_font_size
+	}
+	window_height = window_max_height
+
+	// Then place it with its final size
+	if has_monitor {
+		width := config.window.WindowWidth(monitor, scale)
+		x, y := config.window.WindowPosition(monitor, width, int(window_max_height))
+
+		rl.SetWindowSize(width, int(window_max_height))
+		rl.SetWindowPosition(x, y)
+	} else {
+		rl.SetWindowSize(int(scale_size(int32(config.window.Width), scale)), int(window_max_height))
+	}
+	window_width = int32(rl.GetScreenWidth())
+	rl.ClearWindowState(rl.FlagWindowHidden)
+
+	// Escape is managed by the key bindings
+	rl.SetExitKey(rl.KeyNull)
+
 	// Load fonts with right size to avoid blurry text
 	// See https://github.com/raysan5/raylib/wiki/Frequently-Asked-Questions#why-is-my-font-blurry
-	// Note: I put 256 in last param to make accentuated characters work ... but I don't know why it works
-	font_text = rl.LoadFontEx(config.UI.MainFontFile, config.UI.MainFontSize, nil, 256)
-	font_title = rl.LoadFontEx(config.UI.TitleFontFile, config.UI.TitleFontSize, nil, 256)
+	// The characters of the rules are loaded now, the typed ones when needed
+	font_text = LoadFontChain(append([]string{config.UI.MainFontFile}, config.UI.FallbackFonts...), main_font_size,
+		append(rule_texts(config.Rules), APP_VERSION, "Enter text here ...", SEARCHING_TEXT)...)
+	font_title = LoadFontChain(append([]string{config.UI.TitleFontFile}, config.UI.FallbackFonts...), title_font_size, APP_TITLE)
 
 	// Defer the unloading
-	defer rl.UnloadFont(font_text)
-	defer rl.UnloadFont(font_title)
+	defer font_text.Unload()
+	defer pipeline.Close()
+	defer font_title.Unload()
+
+	// The icons are loaded when they are displayed for the first time
+	if config.UI.ShowIcons {
+		icons = NewIconCache(main_font_size, NewIconTheme(config.UI.IconTheme, default_icon_dirs()))
+		defer icons.Unload()
+	}
 
 	for is_running {
 
 		is_running = !rl.WindowShouldClose()
 
+		if config.clipboard != nil && rl.GetTime()-clipboard_time >= CLIPBOARD_POLL {
+			clipboard_time = rl.GetTime()
+			if err := config.clipboard.Poll(); err != nil {
+				log.Println(err)
+			}
+		}
+
 		//---------- Input ----------//
 
-		// Manage adding text
-		if key := rl.GetCharPressed(); key != 0 {
-			input_char := string(key)
+		// Manage text edition
+		previous_text := input.String()
+		ctrl := rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
+		shift := rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift)
+		alt := rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt)
 
-			// add the character to input
-			input_text += input_char
-			rules_needs_filter = true
+		// add the typed characters (there can be several per frame)
+		for key := rl.GetCharPressed(); key != 0; key = rl.GetCharPressed() {
+			input.Type(key)
 		}
 
-		// Manage deleting text
-		if rl.IsKeyPressed(rl.KeyBackspace) && len(input_text) > 0 {
-			// manage word deletion with Ctrl+Backspace
-			if rl.IsKeyDown(rl.KeyLeftControl) {
-				// Remove trailing spaces
-				input_text = strings.TrimRight(input_text, " ")
-
-				// we search the last space and delete up to it (while keeping it)
-				if idx := strings.LastIndex(input_text, " "); idx > 0 {
-					input_text = input_text[:idx+1]
-				} else { // if none is found, delete everything
-					input_text = ""
-				}
+		// the keys of the input field are not configurable, Alt is
+		// kept for key bindings (see reserved_chords in keys.go)
+		if is_key_pressed(rl.KeyBackspace) && !ctrl && !alt {
+			input.Backspace()
+		} else if is_key_pressed(rl.KeyDelete) && !alt {
+			if ctrl {
+				input.DeleteWordRight()
 			} else {
-				// delete last character
+				input.Delete()
+			}
+		}
 
-				// convert string to rune list
-				tmp := []rune(input_text)
+		// cursor movement, with Ctrl to move by words and Shift to select
+		if is_key_pressed(rl.KeyLeft) && !alt {
+			if ctrl {
+				input.WordLeft(shift)
+			} else {
+				input.Left(shift)
+			}
+		}
+		if is_key_pressed(rl.KeyRight) && !alt {
+			if ctrl {
+				input.WordRight(shift)
+			} else {
+				input.Right(shift)
+			}
+		}
 
-				// remove the last rune
-				tmp = tmp[:len(tmp)-1]
+		// Home/End without Shift are used to navigate in the list
+		if shift && !ctrl && !alt && rl.IsKeyPressed(rl.KeyHome) {
+			input.Home(true)
+		}
+		if shift && !ctrl && !alt && rl.IsKeyPressed(rl.KeyEnd) {
+			input.End(true)
+		}
 
-				// put back into input_text as string
-				input_text = string(tmp)
+		// clipboard and undo/redo shortcuts
+		if ctrl && !alt {
+			switch {
+			case rl.IsKeyPressed(rl.KeyA):
+				input.SelectAll()
+			case rl.IsKeyPressed(rl.KeyC) && input.HasSelection():
+				rl.SetClipboardText(input.SelectedText())
+			case rl.IsKeyPressed(rl.KeyX) && input.HasSelection():
+				rl.SetClipboardText(input.Cut())
+			case is_key_pressed(rl.KeyV):
+				input.Insert(rl.GetClipboardText())
+			case is_key_pressed(rl.KeyZ) && shift, is_key_pressed(rl.KeyY):
+				input.Redo()
+			case is_key_pressed(rl.KeyZ):
+				input.Undo()
 			}
+		}
+
+		if keys.IsPressed(ACTION_DELETE_WORD) {
+			input.DeleteWordLeft()
+		}
+		if keys.IsPressed(ACTION_CLEAR) {
+			input.Clear()
+		}
+
+		// restart the caret blinking on each action
+		if rl.GetKeyPressed() != 0 {
+			caret_time = rl.GetTime()
+		}
+
+		if input.String() != previous_text {
 			rules_needs_filter = true
+			font_text.Require(input.String())
 		}
 
-		// Get filtered rules (only if it needs to)
+		// Get filtered rules (only if it needs to), the providers search in the background
 		if rules_needs_filter {
-			rules_filtered = FilterRules(config.Rules, input_text, config.Search.SearchDescription)
-			SortRules(rules_filtered)
+			query = ParseQuery(input.String(), config.Search.RegexSigil)
+			rules_static = index.Filter(query, config.Search.SearchDescription)
+			rules_async = nil
+			pipeline.Start(query)
 
-			// update number of rules$
-			nb_rules = len(rules_filtered)
+			// reset the list, and close the action menu
+			list.Reset(0)
+			menu_actions = nil
+
+			// mark as filtered
+			rules_needs_filter = false
+			rows_needs_update = true
+		}
 
-			// reset the active element
-			active_element = -1
+		// Get the results of the providers received since the last frame, without waiting
+		if results, changed := pipeline.Poll(); changed {
+			rules_async = results
+			rows_needs_update = true
+		}
 
-			// redo the list of display strings
-			strings_filtered = [][]string{}
-			for i := 0; i < nb_rules; i++ {
-				tmp := rules_filtered[i].GetDisplayStrings(input_text, config.Search.SearchDescription)
-				strings_filtered = append(strings_filtered, tmp)
+		// Redo the rows when the results changed, the selected rule stays selected
+		if rows_needs_update {
+			var selected *Rule
+			if list.Current != -1 {
+				selected = rows_filtered[list.Current].Rule
 			}
+			first := list.First
 
-			// mark as filtered
-			rules_needs_filter = false
+			rules_filtered := slices.Concat(rules_static, rules_async)
+			SortRules(rules_filtered)
+			rows_filtered = GroupRules(rules_filtered, config.Search.Grouping, config.Search.SectionOrder, config.Search.SectionLimits)
 
-			first_display_rule = 0
+			list.Reset(len(rows_filtered))
+			list.ScrollTo(first)
+			if selected != nil {
+				list.Select(slices.IndexFunc(rows_filtered, func(row ListRow) bool { return row.Rule == selected }))
+			}
 
-			// The last rule to display is the minimum between the last rule in the list
-			// and the first rule to display + the number of rules to display
-			// -1 to get the id of the last rule to display
-			last_display_rule = min(nb_rules, first_display_rule+int(config.Search.MaxResults)) - 1
+			// the display strings are computed when the rows are displayed
+			strings_filtered = NewRowStrings(rows_filtered, query, config.Search.SearchDescription)
+
+			rows_needs_update = false
 		}
 
-		// Manage navigation
-		if rl.IsKeyPressed(rl.KeyUp) {
-			if active_element > 0 {
-				active_element--
+		// Manage navigation, in the action menu while it is open
+		view = list
+		if menu_actions != nil {
+			view = menu
+		}
 
-				// if we are not on the first element of the list
-				// and we are on the first element displayed
-				if active_element > 0 && active_element == first_display_rule {
-					// we increase the first rule displayed
-					first_display_rule--
+		if keys.IsPressed(ACTION_NEXT) {
+			view.Next()
+		}
+		if keys.IsPressed(ACTION_PREV) {
+			view.Prev()
+		}
+		if keys.IsPressed(ACTION_PAGE_DOWN) {
+			view.PageDown()
+		}
+		if keys.IsPressed(ACTION_PAGE_UP) {
+			view.PageUp()
+		}
+		if keys.IsPressed(ACTION_FIRST) {
+			view.Home()
+		}
+		if keys.IsPressed(ACTION_LAST) {
+			view.End()
+		}
 
-					// the last is the first + the number of rules to display, or the number of rules in the list (if not enough)
-					last_display_rule = min(first_display_rule+int(config.Search.MaxResults), nb_rules) - 1
-				}
+		if keys.IsPressed(ACTION_CLOSE) {
+			if menu_actions != nil {
+				menu_actions = nil
+			} else {
+				is_running = false
 			}
 		}
 
-		if rl.IsKeyPressed(rl.KeyDown) {
-			if active_element < nb_rules-1 {
-				active_element++
+		// Open the action menu of the selected rule (or the first one), or close it
+		if keys.IsPressed(ACTION_ACTION_MENU) {
+			if menu_actions != nil {
+				menu_actions = nil
+			} else if target := list.Target(); target != -1 {
+				rule := rows_filtered[target].Rule
+
+				menu_actions = rule.Actions()
+				menu_actions = append(menu_actions, Action{
+					Name:     "Copy command line",
+					Run:      func() { rl.SetClipboardText(rule.CommandLine()) },
+					KeepOpen: true,
+				})
+				menu.Reset(len(menu_actions))
+				menu.Home()
+			}
+		}
 
-				// if we are not on the last element of the list
-				// and we are on the last element displayed
-				if active_element < nb_rules-1 && active_element == last_display_rule {
-					// we increase the first rule displayed
-					first_display_rule++
+		if keys.IsPressed(ACTION_PREVIEW) {
+			is_preview = !is_preview
+		}
 
-					// the last is the first + the number of rules to display, or the number of rules in the list (if not enough)
-					last_display_rule = min(first_display_rule+int(config.Search.MaxResults), nb_rules) - 1
-				}
+		// the list shows the action menu when it is open
+		view = list
+		if menu_actions != nil {
+			view = menu
+		}
+
+		// Manage mouse
+		mouse = rl.GetMousePosition()
+		is_clicked = false
+
+		// Position of the elements, the same as in the drawing part
+		rect_input := rl.NewRectangle(10, title_size, float32(window_width)-20, main_size*1.5)
+		list_y := title_size + main_size*2
+		list_height := main_size * float32(config.Search.MaxResults)
+		list_width := float32(window_width)
+		if is_preview && config.UI.PreviewPosition == PREVIEW_RIGHT {
+			list_width = float32(window_width) * (1 - PREVIEW_WIDTH)
+		}
+		is_scrollbar = view.IsScrollable()
+
+		// the wheel scrolls the list without changing the selection
+		if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+			view.Scroll(-int(wheel * SCROLL_LINES))
+		}
+
+		// find the element under the cursor
+		hover_element = -1
+		if !is_dragging_scroll && !is_selecting_text &&
+			mouse.Y >= list_y && mouse.Y < list_y+list_height &&
+			mouse.X >= 0 && mouse.X < list_width &&
+			!(is_scrollbar && mouse.X >= rect_scroll.X) {
+			row := view.First + int((mouse.Y-list_y)/main_size)
+			if row <= view.Last() && view.IsSelectable(row) {
+				hover_element = row
 			}
 		}
 
-		if rl.IsKeyPressed(rl.KeyHome) {
-			active_element = 0
+		if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+			if is_scrollbar && rl.CheckCollisionPointRec(mouse, rect_scroll) {
+				// start dragging the scroll bar from where it has been grabbed
+				is_dragging_scroll = true
+				scroll_grab_offset = mouse.Y - rect_scroll.Y
+
+			} else if rl.CheckCollisionPointRec(mouse, rect_input) {
+				// place the caret, or extend the selection with Shift
+				list.Select(-1)
+				is_selecting_text = true
+				input.SetCursor(text_index_at(font_text, input.String(), mouse.X-rect_input.X-10, main_size), shift)
+				caret_time = rl.GetTime()
+
+			} else if hover_element != -1 {
+				// a click selects the element, a second one executes it
+				if hover_element == last_click_element && rl.GetTime()-last_click_time < DOUBLE_CLICK_TIME {
+					is_clicked = true
+				}
+				view.Current = hover_element
+				last_click_element = hover_element
+				last_click_time = rl.GetTime()
+			}
+		}
 
-			// reset the first rule to display as the first rule of the list
-			first_display_rule = 0
-			// the last is the first + the number of rules to display, or the number of rules in the list (if not enough)
-			last_display_rule = min(first_display_rule+int(config.Search.MaxResults), nb_rules) - 1
+		if rl.IsMouseButtonReleased(rl.MouseButtonLeft) {
+			is_dragging_scroll = false
+			is_selecting_text = false
 		}
 
-		if rl.IsKeyPressed(rl.KeyEnd) {
-			// if there are no rules, it will be active_element = 0 - 1 = -1
-			active_element = nb_rules - 1
+		if is_selecting_text {
+			input.SetCursor(text_index_at(font_text, input.String(), mouse.X-rect_input.X-10, main_size), true)
+		}
 
-			// the last rule to display becomes the last rule
-			last_display_rule = nb_rules - 1
+		if is_dragging_scroll && is_scrollbar {
+			// convert the position of the bar to the first rule displayed
+			vertical_space := list_height - rect_scroll.Height
+			bottom := view.Count - view.Size
+			position := (mouse.Y - scroll_grab_offset - list_y) / vertical_space
 
-			// and the first is last - nb of displayed rules, but cannot be negative
-			first_display_rule = max(0, last_display_rule-int(config.Search.MaxResults)+1)
+			view.ScrollTo(int(position*float32(bottom) + 0.5))
 		}
 
 		// Validation
-		if rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeyKpEnter) {
-			var nb_exec int
-
-			// Only execute if there is at least a rule displayed
-			if nb_rules > 0 {
-				// If no rule is selected, use the first one
-				if active_element == -1 {
-					nb_exec = 0
-				} else {
-					nb_exec = active_element
+		is_keep_open := keys.IsPressed(ACTION_EXECUTE_KEEP_OPEN)
+		is_execute := keys.IsPressed(ACTION_EXECUTE) || is_clicked || is_keep_open
+
+		if is_execute && menu_actions != nil {
+			action := menu_actions[menu.Target()]
+			menu_actions = nil
+
+			if action.Confirm {
+				// the menu asks the confirmation, cancelling is the default
+				action.Confirm = false
+				action.Name = "Confirm: " + action.Name
+				menu_actions = []Action{{Name: "Cancel", Run: func() {}, KeepOpen: true}, action}
+				menu.Reset(len(menu_actions))
+				menu.Home()
+			} else {
+				action.Run()
+
+				// Flag the program to exit, unless asked otherwise
+				if !action.KeepOpen && !is_keep_open {
+					is_running = false
 				}
+			}
 
-				// Execute the rule
-				rules_filtered[nb_exec].Execute()
+		} else if is_execute {
+			// Only execute if there is at least a rule displayed,
+			// if no rule is selected, the first one is used
+			if target := list.Target(); target != -1 {
+				rows_filtered[target].Rule.Execute()
+				preview_rule = nil // its usage has changed
 
-				// Flag the program to exit
-				is_running = false
+				// Flag the program to exit, unless asked otherwise
+				if !is_keep_open {
+					is_running = false
+				}
 			}
 		}
 
 		//---------- Drawing ----------//
 
+		// the action menu may have been closed
+		view = list
+		if menu_actions != nil {
+			view = menu
+		}
+
+		// Resize the window to the displayed rows and the preview
+		rows := config.Search.MaxResults
+		if config.window.Shrink {
+			rows = int32(view.Last() - view.First + 1)
+		}
+		if is_preview && config.UI.PreviewPosition == PREVIEW_BOTTOM {
+			rows += config.UI.PreviewLines
+		}
+
+		if height := title_font_size + (2+rows)*main_font_size; height != window_height {
+			window_height = height
+			rl.SetWindowSize(int(window_width), int(window_height))
+		}
+
+		// Get the preview of the rule that would be executed (only if it changed)
+		if is_preview {
+			var rule *Rule
+			if target := list.Target(); target != -1 {
+				rule = rows_filtered[target].Rule
+			}
+
+			if rule != preview_rule {
+				preview_rule = rule
+				preview_lines = nil
+				if rule != nil {
+					preview_lines = rule.Preview()
+					font_text.Require(preview_lines...)
+				}
+			}
+		}
+
+		// Rows to display, from the rules or the action menu. The fonts are
+		// loaded with their characters before drawing.
+		var display_strings [][]string
+		if menu_actions != nil {
+			for _, action := range menu_actions[view.First : view.Last()+1] {
+				display_strings = append(display_strings, []string{"", action.Name})
+			}
+		} else {
+			display_strings = strings_filtered.Get(view.First, view.Last(), font_text.Require)
+		}
+
 		rl.BeginDrawing()
 
 		rl.ClearBackground(rl.RayWhite)
 
 		coord_main = rl.NewVector2(10, 0)
-		rect_main = rl.NewRectangle(0, 0, WINDOW_WIDTH, title_size)
+		rect_main = rl.NewRectangle(0, 0, float32(window_width), title_size)
 
 		// Title with background
 		rl.DrawRectangleRec(rect_main, color_main)
-		rl.DrawTextEx(font_title, APP_TITLE, coord_main, title_size, 0, color_font_active)
+		font_title.Draw(APP_TITLE, coord_main, title_size, color_font_active)
 
 		// Add version number next to the title
 		coord_text = coord_main
-		coord_text.X += rl.MeasureTextEx(font_title, APP_TITLE, title_size, 0).X + 20 // Title width + some margin
-		coord_text.Y += title_size / 2                                                // half the height of the title
-		rl.DrawTextEx(font_text, APP_VERSION, coord_text, main_size, 0, color_font_active)
+		coord_text.X += font_title.Measure(APP_TITLE, title_size) + 20 // Title width + some margin
+		coord_text.Y += title_size / 2                                 // half the height of the title
+		font_text.Draw(APP_VERSION, coord_text, main_size, color_font_active)
 
 		// Increase Y for next usages
 		coord_main.Y += rect_main.Height
@@ -265,24 +572,50 @@ func GUI_Start(config *Config) {
 		coord_text = coord_main
 		coord_text.X += 10
 		coord_text.Y = rect_text.Y + main_size/3
-		if len(input_text) == 0 {
+		if input.Len() == 0 {
 			tmp_text = "Enter text here ..."
 			tmp_color = color_font_inactive
 		} else {
-			tmp_text = input_text
+			tmp_text = input.String()
 			tmp_color = color_font_active
 		}
 
 		rl.DrawRectangleRec(rect_text, color_text_area)
 		rl.DrawRectangleLinesEx(rect_text, 1, color_box)
-		rl.DrawTextEx(font_text, tmp_text, coord_text, main_size, 0, tmp_color)
+
+		// Selection background, behind the text
+		if input.HasSelection() {
+			sel_start, sel_end := input.Selection()
+			x_start := text_width(font_text, input.String(), sel_start, main_size)
+			x_end := text_width(font_text, input.String(), sel_end, main_size)
+
+			rect_selection := rl.NewRectangle(coord_text.X+x_start, coord_text.Y, x_end-x_start, main_size)
+			rl.DrawRectangleRec(rect_selection, color_main)
+		}
+
+		font_text.Draw(tmp_text, coord_text, main_size, tmp_color)
+
+		// Shown while the providers are searching, on the right of the input
+		if pipeline.IsSearching() {
+			coord_searching := coord_text
+			coord_searching.X = rect_text.X + rect_text.Width - 10 - font_text.Measure(SEARCHING_TEXT, main_size)
+			font_text.Draw(SEARCHING_TEXT, coord_searching, main_size, color_font_inactive)
+		}
+
+		// Caret, blinking every half second
+		if list.Current == -1 && menu_actions == nil && int((rl.GetTime()-caret_time)*2)%2 == 0 {
+			x_caret := coord_text.X + text_width(font_text, input.String(), input.Cursor(), main_size)
+			rl.DrawLineEx(rl.NewVector2(x_caret, coord_text.Y), rl.NewVector2(x_caret, coord_text.Y+main_size), 2, color_font_active)
+		}
 
 		// Increase Y for next usages
 		coord_main.Y += rect_main.Height
 		rect_main.Y += rect_main.Height
 
 		// Scroll bar management
-		if nb_rules > int(config.Search.MaxResults) {
+		rect_main.Width = list_width
+		rect_scroll = rl.Rectangle{}
+		if is_scrollbar {
 			// bar width is relative to font size
 			bar_width := main_size / 2
 
@@ -293,17 +626,17 @@ func GUI_Start(config *Config) {
 			height := main_size * float32(config.Search.MaxResults)
 
 			// height of the actual bar, proportionnal with the number of rules in the list
-			bar_height := height * float32(config.Search.MaxResults) / float32(nb_rules)
+			bar_height := height * float32(view.Size) / float32(view.Count)
 
 			// calculate the space the bar can move
 			vertical_space := height - bar_height
 
 			// calculate the first rule displayed when at the bottom of the rules
-			bottom := nb_rules - int(config.Search.MaxResults)
+			bottom := view.Count - view.Size
 
 			// calculate the vertical offset for the scroll bar
 			//              start position + availlable space for the bar to move proportioned
-			vertical_offset := rect_main.Y + vertical_space*float32(first_display_rule)/float32(bottom)
+			vertical_offset := rect_main.Y + vertical_space*float32(view.First)/float32(bottom)
 
 			// create the scrolling bar to fill the left side
 			rect_scroll = rl.NewRectangle(rect_main.Width, vertical_offset, bar_width, bar_height)
@@ -312,9 +645,20 @@ func GUI_Start(config *Config) {
 		}
 
 		rect_main.Height = main_size
-		for i, texts := range strings_filtered[first_display_rule : last_display_rule+1] {
-			i += first_display_rule
-			if i == active_element {
+		for i, texts := range display_strings {
+			i += view.First
+
+			// Header of a section, it can not be selected
+			if texts == nil {
+				rl.DrawRectangleRec(rect_main, color_header)
+				font_text.Draw(rows_filtered[i].Header, rl.NewVector2(coord_main.X, coord_main.Y), main_size, color_font_header)
+
+				coord_main.Y += rect_main.Height
+				rect_main.Y += rect_main.Height
+				continue
+			}
+
+			if i == view.Current {
 				tmp_color = color_row_selected
 			} else if i%2 == 0 {
 				tmp_color = color_row_even
@@ -324,7 +668,19 @@ func GUI_Start(config *Config) {
 
 			rl.DrawRectangleRec(rect_main, tmp_color)
 
+			// Highlight the element under the mouse cursor
+			if i == hover_element && i != view.Current {
+				rl.DrawRectangleRec(rect_main, rl.Fade(color_row_selected, 0.4))
+			}
+
 			coord_text = coord_main
+
+			// Icon of the rule
+			if icons != nil && menu_actions == nil {
+				draw_rule_icon(icons, font_text, rows_filtered[i].Rule, rl.NewRectangle(coord_text.X, coord_text.Y, main_size, main_size), color_font_active)
+				coord_text.X += main_size + 5
+			}
+
 			for j, tmp_text := range texts {
 				switch j % 2 {
 				case 0:
@@ -332,8 +688,8 @@ func GUI_Start(config *Config) {
 				case 1:
 					tmp_color = color_font_active
 				}
-				rl.DrawTextEx(font_text, tmp_text, coord_text, main_size, 0, tmp_color)
-				coord_text.X += rl.MeasureTextEx(font_text, tmp_text, main_size, 0).X
+				font_text.Draw(tmp_text, coord_text, main_size, tmp_color)
+				coord_text.X += font_text.Measure(tmp_text, main_size)
 			}
 
 			// Increase Y for next usages
@@ -341,8 +697,31 @@ func GUI_Start(config *Config) {
 			rect_main.Y += rect_main.Height
 		}
 
+		// Preview pane, below or on the right of the list
+		if is_preview {
+			rect_text = rl.NewRectangle(0, float32(window_height)-main_size*float32(config.UI.PreviewLines),
+				float32(window_width), main_size*float32(config.UI.PreviewLines))
+			if config.UI.PreviewPosition == PREVIEW_RIGHT {
+				rect_text = rl.NewRectangle(list_width, list_y, float32(window_width)-list_width, float32(window_height)-list_y)
+			}
+
+			rl.DrawRectangleRec(rect_text, color_text_area)
+			rl.DrawRectangleLinesEx(rect_text, 1, color_box)
+
+			// the lines are cut at the border of the pane
+			rl.BeginScissorMode(int32(rect_text.X), int32(rect_text.Y), int32(rect_text.Width), int32(rect_text.Height))
+
+			coord_text = rl.NewVector2(rect_text.X+10, rect_text.Y)
+			for _, line := range preview_lines {
+				font_text.Draw(line, coord_text, main_size, color_font_active)
+				coord_text.Y += main_size
+			}
+
+			rl.EndScissorMode()
+		}
+
 		// Outline rect
-		rect_text = rl.NewRectangle(0, 0, WINDOW_WIDTH, float32(WINDOW_HEIGHT))
+		rect_text = rl.NewRectangle(0, 0, float32(window_width), float32(window_height))
 		rl.DrawRectangleLinesEx(rect_text, 1, color_box)
 
 		rl.EndDrawing()
@@ -353,106 +732,97 @@ func GUI_Start(config *Config) {
 	}
 }
 
-func parse_color(name string, in *string, alt string) rl.Color {
-	var err error
-	var color int64
-	var tmp string
+// Returns the monitor where the window has to be placed (see WindowLayout),
+// false if the monitors are not known
+func select_monitor(layout WindowLayout) (Monitor, bool) {
+	var monitors []Monitor
+	for i := 0; i < rl.GetMonitorCount(); i++ {
+		position := rl.GetMonitorPosition(i)
+		monitors = append(monitors, Monitor{
+			X:      int(position.X),
+			Y:      int(position.Y),
+			Width:  rl.GetMonitorWidth(i),
+			Height: rl.GetMonitorHeight(i),
+		})
+	}
 
-	// check if the input color is the name of a known color
-	col, err := str_to_color(*in)
-	if err == nil {
-		return col
+	if len(monitors) == 0 {
+		return Monitor{}, false
 	}
 
-	// check if the input is a 6 or 8 char hexadecimal number
-	re := regexp.MustCompile("^[0-9A-F]{6}([0-9A-F]{2})?$")
+	mouse_x, mouse_y, has_mouse := cursor_position()
 
-	if re.MatchString(*in) { // if it matches
+	return monitors[layout.SelectMonitor(monitors, rl.GetCurrentMonitor(), mouse_x, mouse_y, has_mouse)], true
+}
 
-		// if it is 6 char long, add FF (the alpha channel) at the end
-		if len(*in) == 6 {
-			tmp = *in + "FF"
-		} else {
-			tmp = *in
-		}
+// Draws the icon of the rule in the rectangle, or a badge with its first letter
+// if it has no icon. The built-in icons are drawn with the tint color.
+func draw_rule_icon(icons *IconCache, font *FontChain, rule *Rule, rect rl.Rectangle, tint rl.Color) {
+	rect = rl.NewRectangle(rect.X+1, rect.Y+1, rect.Width-2, rect.Height-2)
 
-		// parse the string
-		color, err = strconv.ParseInt(tmp, 16, 64)
-		if err != nil {
-			log.Println(*in, "error parsing color -", err)
-		} else {
-			return rl.GetColor(uint(color))
+	if icon := icons.Get(rule.Icon); icon != nil {
+		if !icon.tinted {
+			tint = rl.White
 		}
+
+		source := rl.NewRectangle(0, 0, float32(icon.texture.Width), float32(icon.texture.Height))
+		rl.DrawTexturePro(icon.texture, source, rect, rl.Vector2{}, 0, tint)
+		return
 	}
 
-	// if we are here, in is not a valid color, we use alt
-	log.Printf("%v: invalid color (%v) using default color %v", name, *in, alt)
-	col, err = str_to_color(alt)
-	if err != nil {
-		log.Panic(err)
+	letter := badge_letter(rule.Match)
+	size := rect.Height * 0.8
+	width := font.Measure(letter, size)
+
+	rl.DrawRectangleRounded(rect, 0.3, 6, rl.ColorFromHSV(badge_hue(rule.Match), 0.5, 0.75))
+	font.Draw(letter, rl.NewVector2(rect.X+(rect.Width-width)/2, rect.Y+(rect.Height-size)/2), size, rl.White)
+}
+
+// Returns the texts of the rules that can be displayed
+func rule_texts(rules []*Rule) []string {
+	var texts []string
+
+	for _, rule := range rules {
+		texts = append(texts, rule.Match, rule.Description)
 	}
 
-	// set in string with alt value
-	*in = alt
+	return texts
+}
+
+// Returns true if the key has been pressed or is repeating (kept pressed)
+func is_key_pressed(key int32) bool {
+	return rl.IsKeyPressed(key) || rl.IsKeyPressedRepeat(key)
+}
+
+// Returns the width of the first n runes of the text
+func text_width(font *FontChain, text string, n int, size float32) float32 {
+	runes := []rune(text)
+	n = min(n, len(runes))
+
+	return font.Measure(string(runes[:n]), size)
+}
+
+// Returns the index of the rune boundary of the text that is the closest
+// to the given horizontal position (relative to the start of the text)
+func text_index_at(font *FontChain, text string, x float32, size float32) int {
+	runes := []rune(text)
+	result := 0
+	best := abs_float(x)
+
+	for i := 1; i <= len(runes); i++ {
+		distance := abs_float(x - font.Measure(string(runes[:i]), size))
+		if distance < best {
+			best = distance
+			result = i
+		}
+	}
 
-	return col
+	return result
 }
 
-func str_to_color(in string) (rl.Color, error) {
-	switch strings.ToLower(in) {
-	case "beige":
-		return rl.Beige, nil
-	case "black":
-		return rl.Black, nil
-	case "blank":
-		return rl.Blank, nil
-	case "blue":
-		return rl.Blue, nil
-	case "brown":
-		return rl.Brown, nil
-	case "darkblue":
-		return rl.DarkBlue, nil
-	case "darkbrown":
-		return rl.DarkBrown, nil
-	case "darkgray":
-		return rl.DarkGray, nil
-	case "darkgreen":
-		return rl.DarkGreen, nil
-	case "darkpurple":
-		return rl.DarkPurple, nil
-	case "gold":
-		return rl.Gold, nil
-	case "gray":
-		return rl.Gray, nil
-	case "green":
-		return rl.Green, nil
-	case "lightgray":
-		return rl.LightGray, nil
-	case "lime":
-		return rl.Lime, nil
-	case "magenta":
-		return rl.Magenta, nil
-	case "maroon":
-		return rl.Maroon, nil
-	case "orange":
-		return rl.Orange, nil
-	case "pink":
-		return rl.Pink, nil
-	case "purple":
-		return rl.Purple, nil
-	case "raywhite":
-		return rl.RayWhite, nil
-	case "red":
-		return rl.Red, nil
-	case "skyblue":
-		return rl.SkyBlue, nil
-	case "violet":
-		return rl.Violet, nil
-	case "white":
-		return rl.White, nil
-	case "yellow":
-		return rl.Yellow, nil
-	default:
-		return rl.Blank, errors.New("unknown color")
+func abs_float(x float32) float32 {
+	if x < 0 {
+		return -x
 	}
+	return x
 }
@@ -0,0 +1,332 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const HISTORY_MAX_RESULTS = 200 // the other matching commands are not listed
+
+// Patterns of the commands never shown, when SecretPatterns is not set
+var default_secret_patterns = []string{
+	`(?i)(pass(word|wd)?|secret|token|api[_-]?key)\w*\s*[=:]`,
+	`(?i)authorization:|bearer\s`,
+	`(?i)://[^/\s:@]+:[^/\s@]+@`, // credentials in a URL
+}
+
+// Settings of the shell history provider in the config file
+type HistoryConfig struct {
+	Enabled        bool
+	Keyword        string   `toml:",omitempty"` // a query starting with it lists the commands
+	Bash           string   `toml:",omitempty"` // history files, ~/.bash_history,
+	Zsh            string   `toml:",omitempty"` // ~/.zsh_history and
+	Fish           string   `toml:",omitempty"` // ~/.local/share/fish/fish_history if empty, "none" to ignore one
+	SecretPatterns []string `toml:",omitempty"` // regexes of the commands never shown
+}
+
+// A command of a history file
+type history_entry struct {
+	command string
+	time    time.Time // zero if unknown
+}
+
+// A command of the histories, with its uses
+type history_command struct {
+	shell   string
+	command string
+	last    time.Time
+	count   int
+	order   int // position of its last use in the history file, for the files without time
+}
+
+// HistoryProvider lists the commands of the shell histories (bash, zsh and
+// fish) for the queries starting with its keyword. A command is listed once,
+// the most used and recent first, and runs in the terminal with its shell.
+type HistoryProvider struct {
+	keyword  string
+	files    map[string]*cached_file[history_entry] // by shell
+	secrets  []*regexp.Regexp
+	terminal TerminalConfig
+}
+
+// Checks the settings, sets the default values
+func NewHistoryProvider(config *HistoryConfig, terminal TerminalConfig) (*HistoryProvider, error) {
+	if config.Keyword == "" {
+		config.Keyword = ">"
+	}
+	if config.SecretPatterns == nil {
+		config.SecretPatterns = default_secret_patterns
+	}
+
+	provider := &HistoryProvider{
+		keyword:  config.Keyword,
+		files:    map[string]*cached_file[history_entry]{},
+		terminal: terminal,
+	}
+
+	for _, pattern := range config.SecretPatterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history secret pattern: %w", err)
+		}
+		provider.secrets = append(provider.secrets, regex)
+	}
+
+	shells := []struct {
+		name  string
+		path  string
+		def   string
+		parse func(path string) []history_entry
+	}{
+		{"bash", config.Bash, "~/.bash_history", parse_bash_history},
+		{"zsh", config.Zsh, "~/.zsh_history", parse_zsh_history},
+		{"fish", config.Fish, "~/.local/share/fish/fish_history", parse_fish_history},
+	}
+	for _, shell := range shells {
+		if shell.path == "none" {
+			continue
+		}
+		if shell.path == "" {
+			shell.path = shell.def
+		}
+
+		// the secrets are removed when the file is read, they are never kept
+		parse := shell.parse
+		provider.files[shell.name] = &cached_file[history_entry]{
+			path: expand_home(shell.path),
+			parse: func(path string) []history_entry {
+				return provider.without_secrets(parse(path))
+			},
+		}
+	}
+
+	return provider, nil
+}
+
+func (p *HistoryProvider) Name() string {
+	return "History"
+}
+
+func (p *HistoryProvider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	rest, ok := cut_keyword(query.Text, p.keyword)
+	if !ok {
+		return
+	}
+
+	var rules []*Rule
+	for _, command := range p.commands(time.Now()) {
+		rules = append(rules, p.rule(command))
+	}
+
+	found := ParseQuery(rest, "").Filter(rules, true)
+	SendResults(ctx, results, found[:min(len(found), HISTORY_MAX_RESULTS)])
+}
+
+// Returns the commands of all the histories without duplicates, the best first
+func (p *HistoryProvider) commands(now time.Time) []*history_command {
+	var commands []*history_command
+	index := map[string]*history_command{}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		file, ok := p.files[shell]
+		if !ok {
+			continue
+		}
+
+		for i, entry := range file.get() {
+			// a command of several histories runs with the shell of its last use
+			command, ok := index[entry.command]
+			if !ok {
+				command = &history_command{shell: shell, command: entry.command}
+				index[entry.command] = command
+				commands = append(commands, command)
+			}
+
+			command.count++
+			command.order = i
+			if entry.time.After(command.last) {
+				command.last = entry.time
+				command.shell = shell
+			}
+		}
+	}
+
+	sort.SliceStable(commands, func(i, j int) bool {
+		a, b := commands[i].score(now), commands[j].score(now)
+		if a != b {
+			return a > b
+		}
+		if !commands[i].last.Equal(commands[j].last) {
+			return commands[i].last.After(commands[j].last)
+		}
+		return commands[i].order > commands[j].order
+	})
+
+	return commands
+}
+
+// Returns the score of the command: its number of uses, weighted by the age of
+// the last one (a command used a lot long ago is after one used today)
+func (c *history_command) score(now time.Time) int {
+	weight := 10 // unknown or old
+
+	if !c.last.IsZero() {
+		switch age := now.Sub(c.last); {
+		case age < 4*time.Hour:
+			weight = 100
+		case age < 24*time.Hour:
+			weight = 70
+		case age < 7*24*time.Hour:
+			weight = 50
+		case age < 30*24*time.Hour:
+			weight = 30
+		}
+	}
+
+	return c.count * weight
+}
+
+// Returns the rule running the command in the terminal
+func (p *HistoryProvider) rule(command *history_command) *Rule {
+	description := command.shell
+	if !command.last.IsZero() {
+		description += ", " + command.last.Local().Format("2006-01-02 15:04")
+	}
+	if command.count > 1 {
+		description += ", " + strconv.Itoa(command.count) + " times"
+	}
+
+	rule := &Rule{
+		Match:       strings.ReplaceAll(command.command, "\n", " "),
+		Description: description,
+		Icon:        "terminal",
+	}
+	rule.Exe, rule.Args = p.terminal.Command([]string{command.shell, "-c", command.command})
+
+	return rule
+}
+
+// Returns the entries that do not match a secret pattern
+func (p *HistoryProvider) without_secrets(entries []history_entry) []history_entry {
+	var result []history_entry
+
+	for _, entry := range entries {
+		secret := false
+		for _, regex := range p.secrets {
+			secret = secret || regex.MatchString(entry.command)
+		}
+		if !secret && strings.TrimSpace(entry.command) != "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// Reads a bash history, with the times written by HISTTIMEFORMAT: "#1700000000"
+// before a command
+func parse_bash_history(path string) []history_entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entries []history_entry
+	var last time.Time
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if seconds, ok := strings.CutPrefix(line, "#"); ok {
+			if epoch, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+				last = time.Unix(epoch, 0)
+				continue
+			}
+		}
+
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, history_entry{command: line, time: last})
+		}
+		last = time.Time{}
+	}
+
+	return entries
+}
+
+// Reads a zsh history, in the extended format (": 1700000000:0;command") or not.
+// A line ending with a backslash continues on the next one.
+func parse_zsh_history(path string) []history_entry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entries []history_entry
+	lines := strings.Split(unmetafy_zsh(data), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + "\n" + lines[i]
+		}
+
+		var entry history_entry
+		if rest, ok := strings.CutPrefix(line, ": "); ok {
+			header, command, found := strings.Cut(rest, ";")
+			seconds, _, _ := strings.Cut(header, ":")
+			if epoch, err := strconv.ParseInt(strings.TrimSpace(seconds), 10, 64); found && err == nil {
+				entry.time = time.Unix(epoch, 0)
+				line = command
+			}
+		}
+
+		if entry.command = strings.TrimSpace(line); entry.command != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// zsh writes some bytes as 0x83 followed by the byte xor 32
+func unmetafy_zsh(data []byte) string {
+	var result []byte
+
+	for i := 0; i < len(data); i++ {
+		if data[i] == 0x83 && i+1 < len(data) {
+			i++
+			result = append(result, data[i]^32)
+		} else {
+			result = append(result, data[i])
+		}
+	}
+
+	return string(result)
+}
+
+// Reads a fish history:
+//
+//   - cmd: git status
+//     when: 1700000000
+func parse_fish_history(path string) []history_entry {
+	var entries []history_entry
+
+	for _, line := range read_lines(path) {
+		if command, ok := strings.CutPrefix(line, "- cmd: "); ok {
+			// new lines and backslashes are escaped
+			command = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(command)
+			entries = append(entries, history_entry{command: command})
+		} else if seconds, ok := strings.CutPrefix(line, "when: "); ok && len(entries) != 0 {
+			if epoch, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+				entries[len(entries)-1].time = time.Unix(epoch, 0)
+			}
+		}
+	}
+
+	return entries
+}
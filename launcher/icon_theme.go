@@ -0,0 +1,285 @@
+package launcher
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name of the theme every icon theme inherits from
+const ICON_THEME_FALLBACK = "hicolor"
+
+// IconTheme finds icons by name, following the freedesktop icon theme specification
+// (https://specifications.freedesktop.org/icon-theme-spec/latest/).
+// Only PNG icons are used, as they are the only ones that can be loaded.
+type IconTheme struct {
+	name      string
+	base_dirs []string
+	themes    map[string]*icon_theme_index // parsed index.theme files, nil if not found
+	found     map[string]string            // result of the previous lookups
+}
+
+// A directory of icons of a theme, from the index.theme file
+type icon_dir struct {
+	path      string
+	size      int
+	min_size  int
+	max_size  int
+	threshold int
+	kind      string // Fixed, Scalable or Threshold
+	scale     int
+}
+
+type icon_theme_index struct {
+	dirs     []icon_dir
+	inherits []string
+}
+
+func NewIconTheme(name string, base_dirs []string) *IconTheme {
+	if name == "" {
+		name = ICON_THEME_FALLBACK
+	}
+
+	return &IconTheme{
+		name:      name,
+		base_dirs: base_dirs,
+		themes:    map[string]*icon_theme_index{},
+		found:     map[string]string{},
+	}
+}
+
+// Returns the directories where icons are searched, in order of priority
+func default_icon_dirs() []string {
+	var dirs []string
+
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".icons"))
+	}
+
+	data_home := os.Getenv("XDG_DATA_HOME")
+	if data_home == "" && home != "" {
+		data_home = filepath.Join(home, ".local", "share")
+	}
+	if data_home != "" {
+		dirs = append(dirs, filepath.Join(data_home, "icons"))
+	}
+
+	data_dirs := os.Getenv("XDG_DATA_DIRS")
+	if data_dirs == "" {
+		data_dirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range filepath.SplitList(data_dirs) {
+		dirs = append(dirs, filepath.Join(dir, "icons"))
+	}
+
+	return append(dirs, "/usr/share/pixmaps")
+}
+
+// Returns the path of the icon that is the closest to the size, or "" if not found
+func (t *IconTheme) Lookup(name string, size int) string {
+	key := name + "@" + strconv.Itoa(size)
+	if path, ok := t.found[key]; ok {
+		return path
+	}
+
+	visited := map[string]bool{}
+	path := t.lookup_theme(t.name, name, size, visited)
+	if path == "" {
+		path = t.lookup_theme(ICON_THEME_FALLBACK, name, size, visited)
+	}
+
+	// the icons can also be directly in the base directories
+	for i := 0; path == "" && i < len(t.base_dirs); i++ {
+		if file := filepath.Join(t.base_dirs[i], name+".png"); is_file(file) {
+			path = file
+		}
+	}
+
+	t.found[key] = path
+
+	return path
+}
+
+// Looks for the icon in the theme, then in the themes it inherits from
+func (t *IconTheme) lookup_theme(theme string, name string, size int, visited map[string]bool) string {
+	if visited[theme] {
+		return ""
+	}
+	visited[theme] = true
+
+	index := t.index(theme)
+	if index == nil {
+		return ""
+	}
+
+	// an icon with the right size, otherwise the closest one
+	best := ""
+	best_distance := -1
+
+	for _, dir := range index.dirs {
+		for _, base := range t.base_dirs {
+			file := filepath.Join(base, theme, dir.path, name+".png")
+			if !is_file(file) {
+				continue
+			}
+
+			distance := dir.distance(size)
+			if distance == 0 {
+				return file
+			}
+			if best_distance == -1 || distance < best_distance {
+				best = file
+				best_distance = distance
+			}
+		}
+	}
+
+	if best != "" {
+		return best
+	}
+
+	for _, parent := range index.inherits {
+		if path := t.lookup_theme(parent, name, size, visited); path != "" {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// Returns the parsed index.theme of the theme, nil if the theme is not installed
+func (t *IconTheme) index(theme string) *icon_theme_index {
+	if index, ok := t.themes[theme]; ok {
+		return index
+	}
+
+	var index *icon_theme_index
+	for _, base := range t.base_dirs {
+		sections, err := parse_ini(filepath.Join(base, theme, "index.theme"))
+		if err != nil {
+			continue
+		}
+
+		index = &icon_theme_index{}
+		header := sections["Icon Theme"]
+
+		for _, path := range split_list(header["Directories"]) {
+			values := sections[path]
+			size := atoi_default(values["Size"], 0)
+
+			index.dirs = append(index.dirs, icon_dir{
+				path:      path,
+				size:      size,
+				min_size:  atoi_default(values["MinSize"], size),
+				max_size:  atoi_default(values["MaxSize"], size),
+				threshold: atoi_default(values["Threshold"], 2),
+				kind:      values["Type"],
+				scale:     atoi_default(values["Scale"], 1),
+			})
+		}
+
+		index.inherits = split_list(header["Inherits"])
+		break
+	}
+
+	t.themes[theme] = index
+
+	return index
+}
+
+// Returns how far the size of the icons of the directory is from the size,
+// 0 if they match (see DirectorySizeDistance in the specification)
+func (d icon_dir) distance(size int) int {
+	// the icons for high DPI screens are not used
+	if d.scale != 1 {
+		return 1 << 20
+	}
+
+	switch d.kind {
+	case "Fixed":
+		return abs_int(d.size - size)
+	case "Scalable":
+		if size < d.min_size {
+			return d.min_size - size
+		}
+		if size > d.max_size {
+			return size - d.max_size
+		}
+		return 0
+	default: // Threshold
+		if size < d.size-d.threshold {
+			return d.min_size - size
+		}
+		if size > d.size+d.threshold {
+			return size - d.max_size
+		}
+		return 0
+	}
+}
+
+// Parses a file of the desktop entry format (sections of key=value)
+func parse_ini(path string) (map[string]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections := map[string]map[string]string{}
+	var section map[string]string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			// nothing
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = map[string]string{}
+			sections[line[1:len(line)-1]] = section
+		case section != nil:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return sections, scanner.Err()
+}
+
+// Splits a comma separated list, ignoring the empty values
+func split_list(in string) []string {
+	var result []string
+
+	for _, value := range strings.Split(in, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+func atoi_default(in string, value int) int {
+	if result, err := strconv.Atoi(in); err == nil {
+		return result
+	}
+
+	return value
+}
+
+func abs_int(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func is_file(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
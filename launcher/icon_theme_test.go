@@ -0,0 +1,167 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Creates the files (with empty content) in the directory
+func create_files(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const TEST_HICOLOR_INDEX = `
+[Icon Theme]
+Name=Hicolor
+Directories=16x16/apps,48x48/apps,scalable/apps,32x32@2/apps
+
+[16x16/apps]
+Size=16
+Type=Threshold
+
+[48x48/apps]
+Size=48
+Type=Fixed
+
+[scalable/apps]
+Size=64
+MinSize=8
+MaxSize=512
+Type=Scalable
+
+[32x32@2/apps]
+Size=32
+Scale=2
+Type=Fixed
+`
+
+const TEST_CUSTOM_INDEX = `
+# a theme that only has a few icons
+[Icon Theme]
+Name=Custom
+Inherits=missing, hicolor
+Directories=22x22/apps
+
+[22x22/apps]
+Size=22
+Type=Fixed
+`
+
+func TestIconThemeLookup(t *testing.T) {
+	user := t.TempDir()
+	system := t.TempDir()
+	pixmaps := t.TempDir()
+
+	create_files(t, system, map[string]string{
+		"hicolor/index.theme":            TEST_HICOLOR_INDEX,
+		"hicolor/16x16/apps/editor.png":  "",
+		"hicolor/48x48/apps/editor.png":  "",
+		"hicolor/48x48/apps/browser.png": "",
+		"hicolor/16x16/apps/vector.svg":  "",
+		"hicolor/32x32@2/apps/hidpi.png": "",
+		"custom/index.theme":             TEST_CUSTOM_INDEX,
+		"custom/22x22/apps/editor.png":   "",
+	})
+	create_files(t, user, map[string]string{
+		// the icons of the user replace the system ones
+		"hicolor/48x48/apps/browser.png": "",
+	})
+	create_files(t, pixmaps, map[string]string{
+		"legacy.png": "",
+	})
+
+	base_dirs := []string{user, system, pixmaps}
+
+	var tests = []struct {
+		name  string
+		theme string
+		icon  string
+		size  int
+		want  string
+	}{
+		{"exact size", "hicolor", "editor", 16, filepath.Join(system, "hicolor/16x16/apps/editor.png")},
+		{"threshold", "hicolor", "editor", 18, filepath.Join(system, "hicolor/16x16/apps/editor.png")},
+		{"closest size", "hicolor", "editor", 40, filepath.Join(system, "hicolor/48x48/apps/editor.png")},
+		{"user first", "hicolor", "browser", 48, filepath.Join(user, "hicolor/48x48/apps/browser.png")},
+		{"svg are ignored", "hicolor", "vector", 16, ""},
+		{"hidpi only", "hicolor", "hidpi", 32, filepath.Join(system, "hicolor/32x32@2/apps/hidpi.png")},
+		{"theme first", "custom", "editor", 16, filepath.Join(system, "custom/22x22/apps/editor.png")},
+		{"inherited", "custom", "browser", 48, filepath.Join(user, "hicolor/48x48/apps/browser.png")},
+		{"not installed theme", "missing", "editor", 48, filepath.Join(system, "hicolor/48x48/apps/editor.png")},
+		{"pixmaps", "custom", "legacy", 22, filepath.Join(pixmaps, "legacy.png")},
+		{"not found", "custom", "nothing", 22, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := NewIconTheme(tt.theme, base_dirs)
+
+			if got := theme.Lookup(tt.icon, tt.size); got != tt.want {
+				t.Errorf("got '%v', want '%v'", got, tt.want)
+			}
+
+			// the second lookup uses the cache
+			if got := theme.Lookup(tt.icon, tt.size); got != tt.want {
+				t.Errorf("got '%v' from cache, want '%v'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIconDirDistance(t *testing.T) {
+	var tests = []struct {
+		dir  icon_dir
+		size int
+		want int
+	}{
+		{icon_dir{size: 48, min_size: 48, max_size: 48, kind: "Fixed", scale: 1}, 48, 0},
+		{icon_dir{size: 48, min_size: 48, max_size: 48, kind: "Fixed", scale: 1}, 32, 16},
+		{icon_dir{size: 64, min_size: 8, max_size: 512, kind: "Scalable", scale: 1}, 600, 88},
+		{icon_dir{size: 64, min_size: 8, max_size: 512, kind: "Scalable", scale: 1}, 22, 0},
+		{icon_dir{size: 16, min_size: 16, max_size: 16, threshold: 2, scale: 1}, 18, 0},
+		{icon_dir{size: 16, min_size: 16, max_size: 16, threshold: 2, scale: 1}, 22, 6},
+		{icon_dir{size: 16, min_size: 16, max_size: 16, threshold: 2, scale: 1}, 10, 6},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dir.distance(tt.size); got != tt.want {
+			t.Errorf("%+v, size %d: got %d, want %d", tt.dir, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestBadge(t *testing.T) {
+	var tests = []struct {
+		text string
+		want string
+	}{
+		{"firefox", "F"},
+		{"  (github)", "G"},
+		{"élan", "É"},
+		{"7zip", "7"},
+		{"", "?"},
+		{"---", "?"},
+	}
+
+	for _, tt := range tests {
+		if got := badge_letter(tt.text); got != tt.want {
+			t.Errorf("%v: got '%v', want '%v'", tt.text, got, tt.want)
+		}
+	}
+
+	// the color only depends on the text
+	if badge_hue("Firefox") != badge_hue("firefox") || badge_hue("a") < 0 || badge_hue("a") >= 360 {
+		t.Error("invalid badge hue")
+	}
+}
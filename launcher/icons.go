@@ -0,0 +1,161 @@
+package launcher
+
+import (
+	"hash/fnv"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Icons drawn by the launcher, usable by name in the Icon field of the rules.
+// They are drawn in white, and tinted with the font color.
+var builtin_icons = map[string]func(img *rl.Image, s float32){
+	"app": func(img *rl.Image, s float32) {
+		rl.ImageDrawRectangleLines(img, rl.NewRectangle(s*0.1, s*0.15, s*0.8, s*0.7), int(s/12)+1, rl.White)
+		rl.ImageDrawRectangleRec(img, rl.NewRectangle(s*0.1, s*0.15, s*0.8, s*0.2), rl.White)
+	},
+	"file": func(img *rl.Image, s float32) {
+		rl.ImageDrawRectangleLines(img, rl.NewRectangle(s*0.2, s*0.1, s*0.6, s*0.8), int(s/12)+1, rl.White)
+		rl.ImageDrawTriangle(img, rl.NewVector2(s*0.55, s*0.1), rl.NewVector2(s*0.8, s*0.35), rl.NewVector2(s*0.8, s*0.1), rl.White)
+	},
+	"folder": func(img *rl.Image, s float32) {
+		rl.ImageDrawRectangleRec(img, rl.NewRectangle(s*0.1, s*0.2, s*0.35, s*0.15), rl.White)
+		rl.ImageDrawRectangleRec(img, rl.NewRectangle(s*0.1, s*0.3, s*0.8, s*0.5), rl.White)
+	},
+	"terminal": func(img *rl.Image, s float32) {
+		thick := int32(s/12) + 1
+		rl.ImageDrawRectangleLines(img, rl.NewRectangle(s*0.1, s*0.15, s*0.8, s*0.7), int(thick), rl.White)
+		rl.ImageDrawLineEx(img, rl.NewVector2(s*0.25, s*0.35), rl.NewVector2(s*0.4, s*0.5), thick, rl.White)
+		rl.ImageDrawLineEx(img, rl.NewVector2(s*0.4, s*0.5), rl.NewVector2(s*0.25, s*0.65), thick, rl.White)
+		rl.ImageDrawLineEx(img, rl.NewVector2(s*0.5, s*0.65), rl.NewVector2(s*0.7, s*0.65), thick, rl.White)
+	},
+	"web": func(img *rl.Image, s float32) {
+		thick := int32(s/12) + 1
+		rl.ImageDrawCircle(img, int32(s/2), int32(s/2), int32(s*0.4), rl.White)
+		rl.ImageDrawCircle(img, int32(s/2), int32(s/2), int32(s*0.4)-thick, rl.Blank)
+		rl.ImageDrawLineEx(img, rl.NewVector2(s*0.1, s/2), rl.NewVector2(s*0.9, s/2), thick, rl.White)
+		rl.ImageDrawLineEx(img, rl.NewVector2(s/2, s*0.1), rl.NewVector2(s/2, s*0.9), thick, rl.White)
+	},
+}
+
+// A loaded icon
+type icon_texture struct {
+	texture rl.Texture2D
+	tinted  bool // drawn with the font color (built-in icons)
+}
+
+// IconCache loads the textures of the icons the first time they are drawn
+type IconCache struct {
+	size     int32
+	theme    *IconTheme
+	textures map[string]*icon_texture // nil for the icons that can not be loaded
+}
+
+func NewIconCache(size int32, theme *IconTheme) *IconCache {
+	return &IconCache{
+		size:     size,
+		theme:    theme,
+		textures: map[string]*icon_texture{},
+	}
+}
+
+// Returns the icon, loading it if needed, or nil if it can not be loaded.
+// The icon can be the path of a PNG file, the name of an icon of the icon
+// theme, or the name of a built-in icon.
+func (ic *IconCache) Get(icon string) *icon_texture {
+	if icon == "" {
+		return nil
+	}
+
+	if result, ok := ic.textures[icon]; ok {
+		return result
+	}
+
+	var result *icon_texture
+	if path := ic.resolve(icon); path != "" {
+		result = ic.load_file(path)
+	} else if draw, ok := builtin_icons[strings.ToLower(icon)]; ok {
+		result = ic.load_builtin(draw)
+	}
+
+	if result == nil {
+		log.Printf("icon %v: not found", icon)
+	}
+	ic.textures[icon] = result
+
+	return result
+}
+
+// Unloads all the textures, they will be loaded again when needed
+func (ic *IconCache) Unload() {
+	for _, icon := range ic.textures {
+		if icon != nil {
+			rl.UnloadTexture(icon.texture)
+		}
+	}
+
+	ic.textures = map[string]*icon_texture{}
+}
+
+// Returns the path of the icon file, "" if it is not a file
+func (ic *IconCache) resolve(icon string) string {
+	if strings.ContainsAny(icon, `/\`) || strings.EqualFold(filepath.Ext(icon), ".png") {
+		return expand_env(icon)
+	}
+
+	if ic.theme != nil {
+		return ic.theme.Lookup(icon, int(ic.size))
+	}
+
+	return ""
+}
+
+func (ic *IconCache) load_file(path string) *icon_texture {
+	img := rl.LoadImage(path)
+	if !rl.IsImageValid(img) {
+		return nil
+	}
+	defer rl.UnloadImage(img)
+
+	rl.ImageResize(img, ic.size, ic.size)
+
+	return ic.load_image(img, false)
+}
+
+func (ic *IconCache) load_builtin(draw func(img *rl.Image, s float32)) *icon_texture {
+	img := rl.GenImageColor(int(ic.size), int(ic.size), rl.Blank)
+	defer rl.UnloadImage(img)
+
+	draw(img, float32(ic.size))
+
+	return ic.load_image(img, true)
+}
+
+func (ic *IconCache) load_image(img *rl.Image, tinted bool) *icon_texture {
+	texture := rl.LoadTextureFromImage(img)
+	rl.SetTextureFilter(texture, rl.FilterBilinear)
+
+	return &icon_texture{texture: texture, tinted: tinted}
+}
+
+// Returns the letter displayed in the badge of a rule without icon
+func badge_letter(text string) string {
+	for _, c := range text {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			return string(unicode.ToUpper(c))
+		}
+	}
+
+	return "?"
+}
+
+// Returns the hue (0 to 360) of the badge of a rule, always the same for a text
+func badge_hue(text string) float32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(text)))
+
+	return float32(hash.Sum32() % 360)
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"strings"
+)
+
+// SearchIndex filters a list of rules while the query is typed. The texts of
+// the rules are prepared once, and a query that narrows the previous one
+// (eg: a character is added) only searches in the previous results.
+type SearchIndex struct {
+	entries     []index_entry // rules without MatchRegex
+	regex_rules []*Rule       // rules with a MatchRegex or taking arguments, they are always checked
+
+	previous        Query
+	previous_result []int32 // entries that matched the previous query
+	previous_desc   bool
+	has_previous    bool
+}
+
+// The folded texts of a rule, they are in a single string for all the rules
+// so that they are close in memory
+type index_entry struct {
+	rule        *Rule
+	words       string // see search_text
+	description string
+}
+
+// Prepares the texts of the rules, the rules must not be modified after it
+func NewSearchIndex(rules []*Rule) *SearchIndex {
+	index := &SearchIndex{}
+
+	var texts strings.Builder
+	var positions [][3]int // start of the words, start and end of the description
+
+	for _, rule := range rules {
+		text := rule.search_text()
+
+		if rule.MatchRegex != "" || rule.takes_args() {
+			index.regex_rules = append(index.regex_rules, rule)
+			continue
+		}
+
+		start := texts.Len()
+		texts.WriteString(text.words)
+		middle := texts.Len()
+		texts.WriteString(text.description.text)
+
+		index.entries = append(index.entries, index_entry{rule: rule})
+		positions = append(positions, [3]int{start, middle, texts.Len()})
+	}
+
+	all := texts.String()
+	for i, position := range positions {
+		index.entries[i].words = all[position[0]:position[1]]
+		index.entries[i].description = all[position[1]:position[2]]
+	}
+
+	return index
+}
+
+// Returns the rules that match the query (see Query.Filter),
+// the rules with a MatchRegex or taking arguments are after the other ones
+func (index *SearchIndex) Filter(query Query, search_desc bool) []*Rule {
+	matcher := query.matcher()
+
+	var result []int32
+	match := func(i int32) {
+		entry := &index.entries[i]
+		if matcher.matches_text(entry.rule, entry.words, entry.description, search_desc) {
+			result = append(result, i)
+		}
+	}
+
+	if index.has_previous && search_desc == index.previous_desc && query.narrows(index.previous) {
+		for _, i := range index.previous_result {
+			match(i)
+		}
+	} else {
+		for i := range index.entries {
+			match(int32(i))
+		}
+	}
+
+	index.previous = query
+	index.previous_result = result
+	index.previous_desc = search_desc
+	index.has_previous = true
+
+	rules := make([]*Rule, 0, len(result))
+	for _, i := range result {
+		rules = append(rules, index.entries[i].rule)
+	}
+
+	return append(rules, matcher.filter(index.regex_rules, search_desc)...)
+}
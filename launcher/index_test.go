@@ -0,0 +1,162 @@
+package launcher
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Returns n rules with various words, accents and tags
+func generate_rules(n int) []*Rule {
+	words := []string{"open", "github", "gitlab", "notes", "école", "straße", "terminal", "editor", "calc", "İzmir", "music", "video"}
+
+	rules := make([]*Rule, 0, n)
+	for i := range n {
+		rules = append(rules, &Rule{
+			Match:       fmt.Sprintf("%v %v %d", words[i%len(words)], words[(i/7)%len(words)], i),
+			Description: fmt.Sprintf("Open the %v of %v number %d", words[(i/3)%len(words)], words[(i/11)%len(words)], i),
+			Aliases:     []string{fmt.Sprintf("a%d", i%100)},
+			Tags:        []string{words[(i/5)%len(words)]},
+			Exe:         "dummy.exe",
+			LastUse:     time.Unix(int64(i), 0),
+		})
+	}
+
+	return rules
+}
+
+func TestSearchIndexIncremental(t *testing.T) {
+	rules := generate_rules(2000)
+	rules = append(rules, &Rule{Match: "Ticket", MatchRegex: `^t-(\d+)$`, Description: "Ticket {1}", Exe: "dummy.exe"})
+	if err := rules[len(rules)-1].Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	index := NewSearchIndex(rules)
+
+	// typed, deleted and changed queries
+	inputs := []string{
+		"", "o", "op", "ope", "open", "open ", "open g", "open gi", "open git", "open gitl", "open git", "open g",
+		"open g -", "open g -l", "open g -la", "open g -l", "",
+		"#", "#e", "#ed", "#edi", "#editor", "#editor ", "#editor n", "#editor no", "#edito", "#editor t",
+		"e", "ec", "eco", "ecol", "ÉCOLE", "école 1", "école 12",
+		"\"", "\"open", "\"open the", "\"open the ", "\"open the v", "\"open the vi",
+		"t", "t-", "t-1", "t-12", "t-12x",
+		"re:", "re:^g", "re:^gi", "re:^git", "re:^gitlab 1$", "re:(", "re:", "g",
+		"a1", "a12", "a1", "a",
+	}
+
+	for _, search_desc := range []bool{true, false} {
+		for _, input := range inputs {
+			query := ParseQuery(input, "re:")
+
+			got := RulesToAray(index.Filter(query, search_desc))
+			want := RulesToAray(query.Filter(rules, search_desc))
+			sort.Strings(got)
+			sort.Strings(want)
+
+			if !slices.Equal(got, want) {
+				t.Fatalf("'%v' (%v): got %d rules, want %d", input, search_desc, len(got), len(want))
+			}
+		}
+	}
+}
+
+func TestQueryNarrows(t *testing.T) {
+	var tests = []struct {
+		previous string
+		input    string
+		want     bool
+	}{
+		{"", "a", true},
+		{"a", "ab", true},
+		{"ab", "a", false},
+		{"open", "open git", true},
+		{"open git", "git open", true},
+		{"open git", "open", false},
+		{"open", "open -git", true},
+		{"open -g", "open -gi", false},
+		{"#de", "#dev", true},
+		{"#de", "#dev x", true},
+		{"#dev ", "#devops ", false},
+		{"#dev ", "#DEV x", true},
+		{"ecole", "ÉCOLE 1", true},
+		{"", "re:a", true},
+		{"a", "re:a", false},
+		{"re:a", "re:ab", false},
+		{`"open g`, `"open gi`, true},
+	}
+
+	for _, tt := range tests {
+		if got := ParseQuery(tt.input, "re:").narrows(ParseQuery(tt.previous, "re:")); got != tt.want {
+			t.Errorf("'%v' after '%v': got %v, want %v", tt.input, tt.previous, got, tt.want)
+		}
+	}
+}
+
+// Typing a query in 100k rules, one character per iteration
+func BenchmarkSearchIndexTyping100k(b *testing.B) {
+	index := NewSearchIndex(generate_rules(100_000))
+	inputs := []string{"", "o", "op", "ope", "open", "open ", "open g", "open gi", "open git", "open gith"}
+
+	b.ResetTimer()
+	for i := range b.N {
+		index.Filter(ParseQuery(inputs[i%len(inputs)], "re:"), true)
+	}
+}
+
+// Filtering 100k rules without using the previous results (eg: a character is deleted)
+func BenchmarkSearchIndexFull100k(b *testing.B) {
+	index := NewSearchIndex(generate_rules(100_000))
+	inputs := []string{"open git", "ecole", "#editor n", "a1"}
+
+	b.ResetTimer()
+	for i := range b.N {
+		index.has_previous = false
+		index.Filter(ParseQuery(inputs[i%len(inputs)], "re:"), true)
+	}
+}
+
+// Filtering and sorting, with the display strings of the first rules (see BenchmarkResultRows100k for the GUI)
+func BenchmarkKeystroke100k(b *testing.B) {
+	index := NewSearchIndex(generate_rules(100_000))
+	inputs := []string{"g", "gi", "git", "git o", "git op", "git ope", "git open"}
+
+	b.ResetTimer()
+	for i := range b.N {
+		query := ParseQuery(inputs[i%len(inputs)], "re:")
+		rules := index.Filter(query, true)
+		SortRules(rules)
+		for _, rule := range rules[:min(10, len(rules))] {
+			rule.DisplayStrings(query, true)
+		}
+	}
+}
+
+// The real path of a keystroke in the GUI: filter, sort, group, and the
+// display strings of the visible rows
+func BenchmarkResultRows100k(b *testing.B) {
+	rules := generate_rules(100_000)
+	for i, rule := range rules {
+		if i%3 == 0 {
+			rule.section = "Files"
+		}
+	}
+	index := NewSearchIndex(rules)
+	inputs := []string{"", "g", "gi", "git", "git o", "git op", "git ope", "git open"}
+	require := func(texts ...string) {}
+
+	for _, grouping := range []string{GROUPING_SCORE, GROUPING_SECTION} {
+		b.Run(grouping, func(b *testing.B) {
+			for i := range b.N {
+				query := ParseQuery(inputs[i%len(inputs)], "re:")
+				found := index.Filter(query, true)
+				SortRules(found)
+				rows := GroupRules(found, grouping, nil, nil)
+				NewRowStrings(rows, query, true).Get(0, min(10, len(rows))-1, require)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Names of the actions that can be bound to keys in the [Keys] section
+const (
+	ACTION_NEXT              = "next"
+	ACTION_PREV              = "prev"
+	ACTION_PAGE_DOWN         = "page-down"
+	ACTION_PAGE_UP           = "page-up"
+	ACTION_FIRST             = "first"
+	ACTION_LAST              = "last"
+	ACTION_EXECUTE           = "execute"
+	ACTION_EXECUTE_KEEP_OPEN = "execute-keep-open"
+	ACTION_DELETE_WORD       = "delete-word"
+	ACTION_CLEAR             = "clear"
+	ACTION_CLOSE             = "close"
+	ACTION_ACTION_MENU       = "action-menu"
+	ACTION_PREVIEW           = "preview"
+)
+
+// Actions that are also triggered when the key is kept pressed
+var repeated_actions = map[string]bool{
+	ACTION_NEXT:        true,
+	ACTION_PREV:        true,
+	ACTION_PAGE_DOWN:   true,
+	ACTION_PAGE_UP:     true,
+	ACTION_DELETE_WORD: true,
+}
+
+// Key bindings of each preset.
+// The default preset is the base of the others, they only add bindings.
+var key_presets = map[string]map[string][]string{
+	"default": {
+		ACTION_NEXT:              {"Down"},
+		ACTION_PREV:              {"Up"},
+		ACTION_PAGE_DOWN:         {"PageDown"},
+		ACTION_PAGE_UP:           {"PageUp"},
+		ACTION_FIRST:             {"Home"},
+		ACTION_LAST:              {"End"},
+		ACTION_EXECUTE:           {"Enter", "KpEnter"},
+		ACTION_EXECUTE_KEEP_OPEN: {"Ctrl+Enter", "Ctrl+KpEnter"},
+		ACTION_DELETE_WORD:       {"Ctrl+Backspace"},
+		ACTION_CLEAR:             {"Ctrl+U"},
+		ACTION_CLOSE:             {"Escape"},
+		ACTION_ACTION_MENU:       {"Tab"},
+		ACTION_PREVIEW:           {"Alt+P"},
+	},
+	"emacs": {
+		ACTION_NEXT:        {"Ctrl+N"},
+		ACTION_PREV:        {"Ctrl+P"},
+		ACTION_PAGE_UP:     {"Alt+V"},
+		ACTION_FIRST:       {"Alt+Shift+Comma"},
+		ACTION_LAST:        {"Alt+Shift+Period"},
+		ACTION_EXECUTE:     {"Ctrl+J", "Ctrl+M"},
+		ACTION_DELETE_WORD: {"Ctrl+W", "Alt+Backspace"},
+		ACTION_CLOSE:       {"Ctrl+G"},
+	},
+	"vim": {
+		ACTION_NEXT:        {"Ctrl+J", "Ctrl+N"},
+		ACTION_PREV:        {"Ctrl+K", "Ctrl+P"},
+		ACTION_PAGE_DOWN:   {"Ctrl+F"},
+		ACTION_PAGE_UP:     {"Ctrl+B"},
+		ACTION_DELETE_WORD: {"Ctrl+W"},
+		ACTION_CLOSE:       {"Ctrl+LeftBracket"},
+	},
+}
+
+// Chords used by the input field, they can not be bound to an action
+var reserved_chords = []string{
+	"Left", "Right", "Shift+Left", "Shift+Right",
+	"Ctrl+Left", "Ctrl+Right", "Ctrl+Shift+Left", "Ctrl+Shift+Right",
+	"Shift+Home", "Shift+End",
+	"Backspace", "Delete", "Ctrl+Delete",
+	"Ctrl+A", "Ctrl+C", "Ctrl+X", "Ctrl+V", "Ctrl+Z", "Ctrl+Shift+Z", "Ctrl+Y",
+}
+
+// Names of the keys that can be used in a chord (the letters, digits
+// and function keys are added by init)
+var key_names = map[string]int32{
+	"Space":        rl.KeySpace,
+	"Escape":       rl.KeyEscape,
+	"Enter":        rl.KeyEnter,
+	"Tab":          rl.KeyTab,
+	"Backspace":    rl.KeyBackspace,
+	"Insert":       rl.KeyInsert,
+	"Delete":       rl.KeyDelete,
+	"Right":        rl.KeyRight,
+	"Left":         rl.KeyLeft,
+	"Down":         rl.KeyDown,
+	"Up":           rl.KeyUp,
+	"PageUp":       rl.KeyPageUp,
+	"PageDown":     rl.KeyPageDown,
+	"Home":         rl.KeyHome,
+	"End":          rl.KeyEnd,
+	"Apostrophe":   rl.KeyApostrophe,
+	"Comma":        rl.KeyComma,
+	"Minus":        rl.KeyMinus,
+	"Period":       rl.KeyPeriod,
+	"Slash":        rl.KeySlash,
+	"Semicolon":    rl.KeySemicolon,
+	"Equal":        rl.KeyEqual,
+	"LeftBracket":  rl.KeyLeftBracket,
+	"Backslash":    rl.KeyBackSlash,
+	"RightBracket": rl.KeyRightBracket,
+	"Grave":        rl.KeyGrave,
+	"KpEnter":      rl.KeyKpEnter,
+	"KpAdd":        rl.KeyKpAdd,
+	"KpSubtract":   rl.KeyKpSubtract,
+}
+
+// Same as key_names, with lowercase names
+var key_lookup = map[string]int32{}
+
+func init() {
+	for c := 'A'; c <= 'Z'; c++ {
+		key_names[string(c)] = int32(c)
+	}
+	for c := '0'; c <= '9'; c++ {
+		key_names[string(c)] = int32(c)
+	}
+	for i := int32(0); i < 12; i++ {
+		key_names[fmt.Sprintf("F%d", i+1)] = rl.KeyF1 + i
+	}
+
+	// chords are parsed ignoring the case
+	for name, key := range key_names {
+		key_lookup[strings.ToLower(name)] = key
+	}
+}
+
+// KeyChord is a key with the modifiers that must be held with it
+type KeyChord struct {
+	Key   int32
+	Ctrl  bool
+	Shift bool
+	Alt   bool
+}
+
+// Parses a chord like "Ctrl+Shift+N" (case insensitive)
+func ParseKeyChord(in string) (KeyChord, error) {
+	var chord KeyChord
+
+	parts := strings.Split(in, "+")
+
+	// all the parts but the last one are modifiers
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "ctrl", "control":
+			chord.Ctrl = true
+		case "shift":
+			chord.Shift = true
+		case "alt":
+			chord.Alt = true
+		default:
+			return chord, fmt.Errorf("unknown modifier '%v' in key '%v'", part, in)
+		}
+	}
+
+	key, ok := key_lookup[strings.ToLower(strings.TrimSpace(parts[len(parts)-1]))]
+	if !ok {
+		return chord, fmt.Errorf("unknown key '%v'", in)
+	}
+	chord.Key = key
+
+	return chord, nil
+}
+
+func (c KeyChord) String() string {
+	var parts []string
+
+	if c.Ctrl {
+		parts = append(parts, "Ctrl")
+	}
+	if c.Shift {
+		parts = append(parts, "Shift")
+	}
+	if c.Alt {
+		parts = append(parts, "Alt")
+	}
+
+	// look for the name of the key
+	name := fmt.Sprintf("Key%d", c.Key)
+	for key_name, key := range key_names {
+		if key == c.Key {
+			name = key_name
+			break
+		}
+	}
+
+	return strings.Join(append(parts, name), "+")
+}
+
+// Returns true if the chord key has been pressed with exactly its modifiers.
+// If repeat is true, it is also true while the key is kept pressed.
+func (c KeyChord) IsPressed(repeat bool) bool {
+	if !rl.IsKeyPressed(c.Key) && !(repeat && rl.IsKeyPressedRepeat(c.Key)) {
+		return false
+	}
+
+	ctrl := rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
+	shift := rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift)
+	alt := rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt)
+
+	return c.Ctrl == ctrl && c.Shift == shift && c.Alt == alt
+}
+
+// KeyBindings gives the chords bound to each action
+type KeyBindings map[string][]KeyChord
+
+// Creates the key bindings from a preset ("default" if empty) and
+// the bindings from the config file, that replace the ones of the preset.
+// An error is returned if a chord is invalid or used more than once.
+func NewKeyBindings(preset string, bindings map[string][]string) (KeyBindings, error) {
+	if preset == "" {
+		preset = "default"
+	}
+
+	preset_bindings, ok := key_presets[strings.ToLower(preset)]
+	if !ok {
+		return nil, fmt.Errorf("unknown key preset '%v'", preset)
+	}
+
+	// merge the default preset, the chosen one and the config
+	names := map[string][]string{}
+	for action, chords := range key_presets["default"] {
+		names[action] = chords
+	}
+	for action, chords := range preset_bindings {
+		names[action] = append(append([]string{}, names[action]...), chords...)
+	}
+	for action, chords := range bindings {
+		if _, ok := key_presets["default"][action]; !ok {
+			return nil, fmt.Errorf("unknown action '%v' in key bindings", action)
+		}
+		names[action] = chords
+	}
+
+	// parse the chords and check for conflicts
+	result := KeyBindings{}
+	used := map[KeyChord]string{}
+
+	for _, chord_name := range reserved_chords {
+		chord, err := ParseKeyChord(chord_name)
+		if err != nil {
+			return nil, err
+		}
+		used[chord] = "input field"
+	}
+
+	// sort the actions so that errors are always the same
+	actions := make([]string, 0, len(names))
+	for action := range names {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	var errs []error
+	for _, action := range actions {
+		for _, chord_name := range names[action] {
+			chord, err := ParseKeyChord(chord_name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%v: %w", action, err))
+				continue
+			}
+
+			if other, ok := used[chord]; ok {
+				// the same chord can be listed twice for an action
+				if other != action {
+					errs = append(errs, fmt.Errorf("%v: key '%v' is already used by %v", action, chord_name, other))
+				}
+				continue
+			}
+
+			used[chord] = action
+			result[action] = append(result[action], chord)
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+// Returns true if one of the chords bound to the action has been pressed
+func (kb KeyBindings) IsPressed(action string) bool {
+	for _, chord := range kb[action] {
+		if chord.IsPressed(repeated_actions[action]) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestParseKeyChord(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  KeyChord
+		str   string
+	}{
+		{"Down", KeyChord{Key: rl.KeyDown}, "Down"},
+		{"pagedown", KeyChord{Key: rl.KeyPageDown}, "PageDown"},
+		{"Ctrl+N", KeyChord{Key: rl.KeyN, Ctrl: true}, "Ctrl+N"},
+		{"control+n", KeyChord{Key: rl.KeyN, Ctrl: true}, "Ctrl+N"},
+		{"Shift+Ctrl+Alt+F5", KeyChord{Key: rl.KeyF5, Ctrl: true, Shift: true, Alt: true}, "Ctrl+Shift+Alt+F5"},
+		{"Alt + 1", KeyChord{Key: rl.KeyOne, Alt: true}, "Alt+1"},
+		{"Ctrl+LeftBracket", KeyChord{Key: rl.KeyLeftBracket, Ctrl: true}, "Ctrl+LeftBracket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ans, err := ParseKeyChord(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ans != tt.want {
+				t.Errorf("got %v, want %v", ans, tt.want)
+			}
+
+			if ans.String() != tt.str {
+				t.Errorf("got '%v', want '%v'", ans.String(), tt.str)
+			}
+		})
+	}
+}
+
+func TestParseKeyChordInvalid(t *testing.T) {
+	for _, input := range []string{"", "Ctrl+", "Super+N", "Ctrl+Nope", "F13", "Ctrl+Shift"} {
+		if _, err := ParseKeyChord(input); err == nil {
+			t.Errorf("'%v' should be invalid", input)
+		}
+	}
+}
+
+func TestKeyBindingsPresets(t *testing.T) {
+	for preset := range key_presets {
+		t.Run(preset, func(t *testing.T) {
+			kb, err := NewKeyBindings(preset, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// all the actions have at least a key
+			for action := range key_presets["default"] {
+				if len(kb[action]) == 0 {
+					t.Errorf("no key for action %v", action)
+				}
+			}
+		})
+	}
+
+	// the presets add keys to the default ones
+	kb, _ := NewKeyBindings("emacs", nil)
+	want := []KeyChord{{Key: rl.KeyDown}, {Key: rl.KeyN, Ctrl: true}}
+	if len(kb[ACTION_NEXT]) != 2 || kb[ACTION_NEXT][0] != want[0] || kb[ACTION_NEXT][1] != want[1] {
+		t.Errorf("got %v, want %v", kb[ACTION_NEXT], want)
+	}
+
+	// the empty preset is the default one
+	kb, _ = NewKeyBindings("", nil)
+	if len(kb[ACTION_NEXT]) != 1 {
+		t.Errorf("got %v, want [Down]", kb[ACTION_NEXT])
+	}
+}
+
+func TestKeyBindingsConfig(t *testing.T) {
+	var tests = []struct {
+		name     string
+		preset   string
+		bindings map[string][]string
+		err      string // part of the expected error, empty if valid
+	}{
+		{"override", "default", map[string][]string{"next": {"Ctrl+J"}}, ""},
+		{"override preset", "vim", map[string][]string{"next": {"Down"}, "prev": {"Up"}}, ""},
+		{"same key twice", "default", map[string][]string{"close": {"Escape", "escape"}}, ""},
+		{"unknown preset", "nano", nil, "unknown key preset"},
+		{"unknown action", "default", map[string][]string{"jump": {"J"}}, "unknown action"},
+		{"invalid key", "default", map[string][]string{"next": {"Ctrl+Nope"}}, "unknown key"},
+		{"conflict", "default", map[string][]string{"close": {"Down"}}, "already used by"},
+		{"conflict preset", "emacs", map[string][]string{"clear": {"Ctrl+N"}}, "already used by"},
+		{"conflict input", "default", map[string][]string{"first": {"Ctrl+A"}}, "already used by input field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyBindings(tt.preset, tt.bindings)
+
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("got error '%v', want '%v'", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package launcher
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Positions of the preview pane
+const (
+	PREVIEW_BOTTOM = "bottom"
+	PREVIEW_RIGHT  = "right"
+)
+
+const (
+	PREVIEW_MAX_LINES = 20        // number of lines of the file previews
+	PREVIEW_MAX_BYTES = 64 * 1024 // number of bytes read by the file previews
+)
+
+// Returns the lines displayed in the preview pane for the rule: the command
+// that will run and its usage, followed by the preview of the provider if any
+func (r *Rule) Preview() []string {
+	exe, args, dir := r.Command()
+
+	quoted_args := "(none)"
+	if len(args) != 0 {
+		parts := []string{}
+		for _, arg := range args {
+			parts = append(parts, quote_arg(arg))
+		}
+		quoted_args = strings.Join(parts, " ")
+	}
+
+	if dir == "" {
+		dir = "(launcher directory)"
+		if wd, err := os.Getwd(); err == nil {
+			dir = wd
+		}
+	}
+
+	last_use := "never"
+	if !r.LastUse.IsZero() && r.LastUse.After(time.Unix(0, 0)) {
+		last_use = r.LastUse.Local().Format("2006-01-02 15:04")
+	}
+
+	lines := []string{
+		"Program:   " + exe,
+		"Arguments: " + quoted_args,
+		"Directory: " + dir,
+		"Last used: " + last_use,
+		fmt.Sprintf("Used:      %d times", r.UseCount),
+	}
+
+	// a snippet shows its text instead of the command, without reading the clipboard
+	if r.Snippet != "" {
+		snippet := r.ExpandSnippet(time.Now(), func() string { return "{clipboard}" })
+		lines = append([]string{"Snippet copied to the clipboard:"}, strings.Split(snippet, "\n")...)
+		lines = append(lines, "", "Last used: "+last_use, fmt.Sprintf("Used:      %d times", r.UseCount))
+	}
+
+	if r.preview != nil {
+		lines = append(lines, "")
+		lines = append(lines, r.preview()...)
+	}
+
+	return lines
+}
+
+// Returns the first lines of a text file, or the content of a directory
+func FilePreview(path string, max_lines int) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	if info.IsDir() {
+		return dir_preview(path, max_lines)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, PREVIEW_MAX_BYTES))
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	// the end of the data can be a truncated character
+	if len(data) == PREVIEW_MAX_BYTES {
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+
+	if !utf8.Valid(data) || strings.ContainsRune(string(data), 0) {
+		return []string{fmt.Sprintf("(binary file, %d bytes)", info.Size())}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) > max_lines {
+		lines = lines[:max_lines]
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(line, "\t", "    ")
+	}
+
+	return lines
+}
+
+// Returns the names of the entries of a directory, the directories first
+func dir_preview(path string, max_lines int) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	if len(entries) == 0 {
+		return []string{"(empty directory)"}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].IsDir() && !entries[j].IsDir()
+	})
+
+	var lines []string
+	for i, entry := range entries {
+		if i == max_lines-1 && len(entries) > max_lines {
+			lines = append(lines, fmt.Sprintf("... (%d more)", len(entries)-i))
+			break
+		}
+
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		lines = append(lines, name)
+	}
+
+	return lines
+}
@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRulePreview(t *testing.T) {
+	t.Setenv("LAUNCHER_TEST_DIR", "/home/me")
+
+	rule := Rule{
+		Match:       "ex",
+		Description: "Example",
+		Exe:         "${LAUNCHER_TEST_DIR}/bin/app",
+		Args:        []string{"arg 1", "arg2"},
+		WorkDir:     "/tmp",
+		LastUse:     time.Unix(0, 0),
+	}
+
+	want := []string{
+		"Program:   /home/me/bin/app",
+		"Arguments: \"arg 1\" arg2",
+		"Directory: /tmp",
+		"Last used: never",
+		"Used:      0 times",
+	}
+	if got := rule.Preview(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// the provider preview is added after the command
+	rule.Args = nil
+	rule.UseCount = 3
+	rule.LastUse = time.Date(2024, 5, 17, 9, 30, 0, 0, time.Local)
+	rule.preview = func() []string { return []string{"first line"} }
+
+	want = []string{
+		"Program:   /home/me/bin/app",
+		"Arguments: (none)",
+		"Directory: /tmp",
+		"Last used: 2024-05-17 09:30",
+		"Used:      3 times",
+		"",
+		"first line",
+	}
+	if got := rule.Preview(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// without working directory, it is the one of the launcher
+	rule.WorkDir = ""
+	wd, _ := os.Getwd()
+	if got := rule.Preview()[2]; got != "Directory: "+wd {
+		t.Errorf("got '%v', want the launcher directory", got)
+	}
+}
+
+func TestFilePreview(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// text files, only the first lines
+	path := write("text.txt", "line 1\r\n\tline 2\nline 3\nline 4\n")
+	if got, want := FilePreview(path, 2), []string{"line 1", "    line 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := FilePreview(path, 10); len(got) != 5 || got[3] != "line 4" {
+		t.Errorf("got %q, want the 4 lines", got)
+	}
+
+	// binary files
+	path = write("binary.bin", "abc\x00def")
+	if got := FilePreview(path, 10); len(got) != 1 || !strings.Contains(got[0], "binary file, 7 bytes") {
+		t.Errorf("got %q, want a binary file", got)
+	}
+
+	// a long file is cut without breaking the last character
+	path = write("long.txt", strings.Repeat("a", PREVIEW_MAX_BYTES-1)+"é")
+	if got := FilePreview(path, 10); len(got) != 1 || len(got[0]) != PREVIEW_MAX_BYTES-1 {
+		t.Errorf("got %d lines, want a single line of text", len(got))
+	}
+
+	// directories, with the sub directories first
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	want := []string{"sub/", "binary.bin", "long.txt", "text.txt"}
+	if got := FilePreview(dir, 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	want = []string{"sub/", "binary.bin", "... (2 more)"}
+	if got := FilePreview(dir, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got := FilePreview(t.TempDir(), 10); !reflect.DeepEqual(got, []string{"(empty directory)"}) {
+		t.Errorf("got %q, want an empty directory", got)
+	}
+
+	// missing files give the error
+	if got := FilePreview(filepath.Join(dir, "missing"), 10); len(got) != 1 {
+		t.Errorf("got %q, want an error", got)
+	}
+}
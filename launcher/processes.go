@@ -0,0 +1,205 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Settings of the process list in the config file
+type ProcessesConfig struct {
+	Enabled  bool
+	Keyword  string `toml:",omitempty"` // a query starting with it lists the processes
+	AllUsers bool   // also list the processes of the other users
+}
+
+// A running process
+type process_info struct {
+	pid     int
+	name    string // from comm
+	command string // from cmdline, the arguments separated by spaces
+	uid     int
+	memory  int64 // resident memory in bytes
+}
+
+// ProcessProvider lists the running processes for the queries starting with
+// its keyword (eg: "kill fire"), read from /proc (Linux). Selecting a process
+// sends it SIGTERM, and the action menu can send SIGKILL after a confirmation.
+type ProcessProvider struct {
+	keyword   string
+	all_users bool
+	proc      string                                  // /proc, or a fake one for the tests
+	uid       int                                     // user of the launcher
+	signal    func(pid int, sig syscall.Signal) error // sends a signal to a process
+}
+
+func NewProcessProvider(config *ProcessesConfig) *ProcessProvider {
+	if config.Keyword == "" {
+		config.Keyword = "kill"
+	}
+
+	return &ProcessProvider{
+		keyword:   config.Keyword,
+		all_users: config.AllUsers,
+		proc:      "/proc",
+		uid:       os.Getuid(),
+		signal:    send_signal,
+	}
+}
+
+func send_signal(pid int, sig syscall.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return process.Signal(sig)
+}
+
+func (p *ProcessProvider) Name() string {
+	return "Processes"
+}
+
+// The processes whose name, command or PID match the rest of the query,
+// the ones using the most memory first
+func (p *ProcessProvider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	rest, ok := cut_keyword(query.Text, p.keyword)
+	if !ok {
+		return
+	}
+
+	processes := read_processes(p.proc)
+	sort.SliceStable(processes, func(i, j int) bool { return processes[i].memory > processes[j].memory })
+
+	users := map[int]string{}
+	var rules []*Rule
+	for _, process := range processes {
+		if process.pid == os.Getpid() || (!p.all_users && process.uid != p.uid) {
+			continue
+		}
+
+		if _, ok := users[process.uid]; !ok {
+			users[process.uid] = user_name(process.uid)
+		}
+
+		rules = append(rules, p.rule(process, users[process.uid]))
+	}
+
+	SendResults(ctx, results, ParseQuery(rest, "").Filter(rules, true))
+}
+
+// Returns the rule stopping the process
+func (p *ProcessProvider) rule(process process_info, user string) *Rule {
+	pid := process.pid
+	description := fmt.Sprintf("PID %d, %v, %v", pid, user, format_size(process.memory))
+	if process.command != "" {
+		description += " - " + process.command
+	}
+
+	kill := func(sig syscall.Signal) func() {
+		return func() {
+			if err := p.signal(pid, sig); err != nil {
+				log.Printf("Could not send %v to %d: %v\n", sig, pid, err)
+			}
+		}
+	}
+
+	return &Rule{
+		Match:       process.name,
+		Description: description,
+		Icon:        "app",
+		execute:     kill(syscall.SIGTERM),
+		actions:     []Action{{Name: "Force kill (SIGKILL)", Run: kill(syscall.SIGKILL), Confirm: true}},
+		preview: func() []string {
+			return []string{
+				"Sends SIGTERM to the process",
+				fmt.Sprintf("PID:     %d", pid),
+				"User:    " + user,
+				"Memory:  " + format_size(process.memory),
+				"Command: " + process.command,
+			}
+		},
+	}
+}
+
+// Returns the processes of a /proc directory, the ones that can not be read are ignored
+func read_processes(proc string) []process_info {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return nil
+	}
+
+	var processes []process_info
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(proc, entry.Name())
+
+		comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+		if err != nil {
+			continue
+		}
+		process := process_info{pid: pid, name: strings.TrimSpace(string(comm)), uid: -1}
+
+		// the arguments are separated by null characters
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			process.command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+		}
+
+		for _, line := range read_lines(filepath.Join(dir, "status")) {
+			key, value, _ := strings.Cut(line, ":")
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				continue
+			}
+
+			switch key {
+			case "Uid": // real, effective, saved and file system
+				process.uid, _ = strconv.Atoi(fields[0])
+			case "VmRSS": // in kB
+				kb, _ := strconv.ParseInt(fields[0], 10, 64)
+				process.memory = kb * 1024
+			}
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes
+}
+
+// Returns the name of the user, or its id if it is unknown
+func user_name(uid int) string {
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		return u.Username
+	}
+
+	return strconv.Itoa(uid)
+}
+
+// Returns a size in bytes in a readable form, eg: 1.5 MB
+func format_size(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	return fmt.Sprintf("%.1f %v", value, units[unit])
+}
@@ -0,0 +1,120 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+// Writes a fake /proc directory, with a process per entry
+func write_proc(t *testing.T, processes []process_info) string {
+	t.Helper()
+
+	proc := t.TempDir()
+	for _, process := range processes {
+		dir := filepath.Join(proc, strconv.Itoa(process.pid))
+		os.Mkdir(dir, 0755)
+
+		os.WriteFile(filepath.Join(dir, "comm"), []byte(process.name+"\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "cmdline"), []byte(process.command), 0644)
+		status := "Name:\t" + process.name + "\nUid:\t" + strconv.Itoa(process.uid) + "\t" + strconv.Itoa(process.uid) + "\t0\t0\n"
+		if process.memory != 0 {
+			status += "VmRSS:\t" + strconv.FormatInt(process.memory/1024, 10) + " kB\n"
+		}
+		os.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644)
+	}
+
+	// not processes
+	os.Mkdir(filepath.Join(proc, "sys"), 0755)
+	os.WriteFile(filepath.Join(proc, "uptime"), []byte("1.0 2.0\n"), 0644)
+	os.Mkdir(filepath.Join(proc, "999"), 0755) // exited while reading
+
+	return proc
+}
+
+func TestProcessProvider(t *testing.T) {
+	proc := write_proc(t, []process_info{
+		{pid: 100, name: "firefox", command: "/usr/lib/firefox/firefox\x00-P\x00work", uid: 1000, memory: 512 * 1024 * 1024},
+		{pid: 200, name: "Web Content", command: "/usr/lib/firefox/firefox\x00-contentproc", uid: 1000, memory: 100 * 1024 * 1024},
+		{pid: 300, name: "sshd", command: "sshd: /usr/sbin/sshd", uid: 0, memory: 8 * 1024 * 1024},
+		{pid: 400, name: "kworker/0:1", uid: 1000},
+	})
+
+	var signals []string
+	provider := NewProcessProvider(&ProcessesConfig{})
+	provider.proc = proc
+	provider.uid = 1000
+	provider.signal = func(pid int, sig syscall.Signal) error {
+		signals = append(signals, strconv.Itoa(pid)+" "+sig.String())
+		return nil
+	}
+
+	search := func(input string) []*Rule {
+		results := make(chan []*Rule, 1)
+		provider.Search(context.Background(), ParseQuery(input, ""), results)
+		close(results)
+		return <-results
+	}
+
+	tests := []struct {
+		input     string
+		all_users bool
+		want      []string
+	}{
+		{"kill", false, []string{"firefox", "Web Content", "kworker/0:1"}}, // the most memory first
+		{"kill fire", false, []string{"firefox", "Web Content"}},           // in the name or the command
+		{"kill 200", false, []string{"Web Content"}},                       // PID
+		{"kill sshd", false, nil},                                          // another user
+		{"kill sshd", true, []string{"sshd"}},
+		{"fire", false, nil}, // without the keyword
+	}
+
+	for _, test := range tests {
+		provider.all_users = test.all_users
+		if got := RulesToAray(search(test.input)); !slices.Equal(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.input, got, test.want)
+		}
+	}
+
+	provider.all_users = false
+	firefox := search("kill firefox")[0]
+	if want := "PID 100, " + user_name(1000) + ", 512.0 MB - /usr/lib/firefox/firefox -P work"; firefox.Description != want {
+		t.Errorf("description = %q, want %q", firefox.Description, want)
+	}
+
+	// Enter sends SIGTERM, the action menu SIGKILL after a confirmation
+	firefox.Execute()
+	actions := firefox.Actions()
+	kill := actions[len(actions)-1]
+	if !kill.Confirm {
+		t.Errorf("action %q without confirmation", kill.Name)
+	}
+	kill.Run()
+
+	if want := []string{"100 terminated", "100 killed"}; !slices.Equal(signals, want) {
+		t.Errorf("signals = %v, want %v", signals, want)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 kB"},
+		{512 * 1024 * 1024, "512.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+
+	for _, test := range tests {
+		if got := format_size(test.size); got != test.want {
+			t.Errorf("format_size(%d) = %q, want %q", test.size, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package launcher
+
+import (
+	"context"
+	"strings"
+)
+
+// Provider gives rules for a query, like the rules of the config file but
+// computed while typing (files, script results, ...).
+// Search is called in its own goroutine for each query: it sends its results
+// in one or several batches, and must return soon after the context is
+// cancelled (a new query has been typed).
+type Provider interface {
+	Name() string // section of its rules
+	Search(ctx context.Context, query Query, results chan<- []*Rule)
+}
+
+// Sends a batch of results, returns false if the query has been cancelled
+func SendResults(ctx context.Context, results chan<- []*Rule, rules []*Rule) bool {
+	select {
+	case results <- rules:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Pipeline runs the providers in the background for the current query.
+// Its methods are called by the GUI only, the providers only use channels.
+type Pipeline struct {
+	providers  []Provider
+	cancel     context.CancelFunc
+	generation uint64     // number of the current query
+	batches    chan batch // results of all the queries, the stale ones are dropped
+	pending    int        // providers still searching for the current query
+	results    []*Rule    // results of the current query
+}
+
+// Results of a provider for a query
+type batch struct {
+	generation uint64
+	rules      []*Rule
+	done       bool // the provider has finished
+}
+
+func NewPipeline(providers []Provider) *Pipeline {
+	return &Pipeline{
+		providers: providers,
+		batches:   make(chan batch, 64),
+	}
+}
+
+// Cancels the previous query, and starts the providers for the new one
+func (p *Pipeline) Start(query Query) {
+	p.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.generation++
+	p.pending = len(p.providers)
+	p.results = nil
+
+	for _, provider := range p.providers {
+		go p.run(ctx, p.generation, provider, query)
+	}
+}
+
+// Cancels the current query
+func (p *Pipeline) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
+
+// Runs a provider, its results are sent with the number of the query
+func (p *Pipeline) run(ctx context.Context, generation uint64, provider Provider, query Query) {
+	results := make(chan []*Rule)
+
+	go func() {
+		defer close(results)
+		provider.Search(ctx, query, results)
+	}()
+
+	section := provider.Name()
+	for rules := range results {
+		for _, rule := range rules {
+			if rule.section == "" {
+				rule.section = section
+			}
+		}
+
+		select {
+		case p.batches <- batch{generation: generation, rules: rules}:
+		case <-ctx.Done():
+		}
+	}
+
+	select {
+	case p.batches <- batch{generation: generation, done: true}:
+	case <-ctx.Done():
+	}
+}
+
+// Returns the results of the current query received so far, without waiting.
+// changed is true if there are new results or if the search has finished.
+// The results of the previous queries are dropped.
+func (p *Pipeline) Poll() (results []*Rule, changed bool) {
+	for {
+		select {
+		case b := <-p.batches:
+			if b.generation != p.generation {
+				continue
+			}
+
+			if b.done {
+				p.pending--
+			} else {
+				p.results = append(p.results, b.rules...)
+			}
+			changed = true
+
+		default:
+			return p.results, changed
+		}
+	}
+}
+
+// Cancels the current query, and closes the providers that need it
+// (eg: stops the programs of the scripts)
+func (p *Pipeline) Close() {
+	p.Stop()
+
+	for _, provider := range p.providers {
+		if closer, ok := provider.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+}
+
+// Returns true while providers are searching for the current query
+func (p *Pipeline) IsSearching() bool {
+	return p.pending > 0
+}
+
+// Returns the rest of the text if it starts with the keyword (ignoring case),
+// followed by a space or nothing. Example: "cb hello" with "cb" gives "hello".
+func cut_keyword(text string, keyword string) (string, bool) {
+	if len(text) < len(keyword) || !strings.EqualFold(text[:len(keyword)], keyword) {
+		return "", false
+	}
+
+	rest := text[len(keyword):]
+	if rest != "" && rest[0] != ' ' {
+		return "", false
+	}
+
+	return strings.TrimLeft(rest, " "), true
+}
@@ -0,0 +1,152 @@
+package launcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Provider for the tests, gives a rule named after the query after a delay
+type test_provider struct {
+	name      string
+	delays    map[string]time.Duration // delay of the results, by query text
+	cancelled chan string              // texts of the queries that have been cancelled
+}
+
+func (p *test_provider) Name() string {
+	return p.name
+}
+
+func (p *test_provider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	select {
+	case <-time.After(p.delays[query.Text]):
+		SendResults(ctx, results, []*Rule{{Match: query.Text, Description: "result", Exe: "dummy.exe"}})
+	case <-ctx.Done():
+		if p.cancelled != nil {
+			p.cancelled <- query.Text
+		}
+	}
+}
+
+// Polls the pipeline until the search has finished
+func wait_pipeline(t *testing.T, pipeline *Pipeline) []*Rule {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		results, _ := pipeline.Poll()
+		if !pipeline.IsSearching() {
+			return results
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("the search has not finished")
+	return nil
+}
+
+func TestPipelineStaleResults(t *testing.T) {
+	provider := &test_provider{
+		name:   "Test",
+		delays: map[string]time.Duration{"slow": 50 * time.Millisecond, "fast": 0},
+	}
+	pipeline := NewPipeline([]Provider{provider})
+	defer pipeline.Stop()
+
+	// the results of the first query would arrive after the ones of the second
+	pipeline.Start(ParseQuery("slow", ""))
+	pipeline.Start(ParseQuery("fast", ""))
+
+	results := wait_pipeline(t, pipeline)
+	if len(results) != 1 || results[0].Match != "fast" {
+		t.Fatalf("results = %v, want [fast]", RulesToAray(results))
+	}
+	if results[0].Section() != "Test" {
+		t.Errorf("section = %q, want the provider name", results[0].Section())
+	}
+
+	// nothing more arrives later
+	time.Sleep(100 * time.Millisecond)
+	results, changed := pipeline.Poll()
+	if changed || len(results) != 1 {
+		t.Errorf("results changed after the end of the search: %v", RulesToAray(results))
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	provider := &test_provider{
+		name:      "Test",
+		delays:    map[string]time.Duration{"a": time.Hour, "ab": time.Hour},
+		cancelled: make(chan string, 2),
+	}
+	pipeline := NewPipeline([]Provider{provider})
+
+	pipeline.Start(ParseQuery("a", ""))
+	if !pipeline.IsSearching() {
+		t.Error("IsSearching() = false after Start")
+	}
+
+	// a new query cancels the previous one
+	pipeline.Start(ParseQuery("ab", ""))
+	select {
+	case text := <-provider.cancelled:
+		if text != "a" {
+			t.Errorf("cancelled query = %q, want %q", text, "a")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the previous query has not been cancelled")
+	}
+
+	pipeline.Stop()
+	select {
+	case text := <-provider.cancelled:
+		if text != "ab" {
+			t.Errorf("cancelled query = %q, want %q", text, "ab")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query has not been cancelled by Stop")
+	}
+}
+
+func TestPipelineSeveralProviders(t *testing.T) {
+	fast := &test_provider{name: "Fast", delays: map[string]time.Duration{}}
+	slow := &test_provider{name: "Slow", delays: map[string]time.Duration{"q": 20 * time.Millisecond}}
+	pipeline := NewPipeline([]Provider{fast, slow})
+	defer pipeline.Stop()
+
+	pipeline.Start(ParseQuery("q", ""))
+	results := wait_pipeline(t, pipeline)
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	// no provider: nothing to wait for
+	empty := NewPipeline(nil)
+	empty.Start(ParseQuery("q", ""))
+	if empty.IsSearching() {
+		t.Error("IsSearching() = true without providers")
+	}
+}
+
+func TestCutKeyword(t *testing.T) {
+	tests := []struct {
+		text string
+		rest string
+		ok   bool
+	}{
+		{"cb", "", true},
+		{"cb hello world", "hello world", true},
+		{"CB   hello", "hello", true},
+		{"cbhello", "", false},
+		{"c", "", false},
+		{"hello cb", "", false},
+	}
+
+	for _, test := range tests {
+		rest, ok := cut_keyword(test.text, "cb")
+		if rest != test.rest || ok != test.ok {
+			t.Errorf("cut_keyword(%q) = %q, %v, want %q, %v", test.text, rest, ok, test.rest, test.ok)
+		}
+	}
+}
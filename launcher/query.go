@@ -0,0 +1,391 @@
+package launcher
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed search input.
+// Example: `#dev open "git hub" -lab` searches the rules with the tag dev
+// that match open and "git hub", but not lab.
+// With the regex sigil (eg: `/^ex\d`), the rest of the input is a regular expression.
+type Query struct {
+	Tag         string         // from a #tag prefix, "" if none
+	TagComplete bool           // the tag is followed by a space (otherwise it is being typed)
+	Terms       []string       // terms that must all match, in any order
+	Excluded    []string       // terms that must not match
+	Text        string         // the input without the tag, matched by the MatchRegex of the rules
+	Regex       *regexp.Regexp // regex of the regex query mode, nil otherwise
+	Error       error          // the regex is not valid, nothing matches
+}
+
+// Finds something in a folded text, returns its position in the original text
+// or -1, -1 if it is not found. With words, it has to be at the start of a word.
+type finder func(f *folded_text, words bool) (int, int)
+
+// query_matcher is a query prepared to be matched with many rules
+type query_matcher struct {
+	query    Query
+	tag      string   // folded tag
+	terms    []string // folded terms
+	excluded []string // folded excluded terms
+	regex    finder   // finder of the regex, nil if it is not a regex query
+}
+
+// Parses the input: terms are separated by spaces, a quoted phrase is a single
+// term (the closing quote is optional) and a term starting with - is excluded.
+// If the input starts with the regex sigil (and it is not empty), the rest is a
+// regular expression, ignoring case unless it starts with (?-i).
+func ParseQuery(input string, regex_sigil string) Query {
+	var q Query
+
+	q.Tag, input, q.TagComplete = split_tag_query(input)
+	q.Text = strings.TrimSpace(input)
+
+	if pattern, ok := strings.CutPrefix(q.Text, regex_sigil); ok && regex_sigil != "" {
+		if pattern != "" {
+			q.Regex, q.Error = regexp.Compile("(?i)" + pattern)
+		}
+		return q
+	}
+
+	for {
+		input = strings.TrimLeft(input, " ")
+		if input == "" {
+			break
+		}
+
+		excluded := false
+		if strings.HasPrefix(input, "-") {
+			excluded = true
+			input = input[1:]
+		}
+
+		var term string
+		if strings.HasPrefix(input, `"`) {
+			term, input, _ = strings.Cut(input[1:], `"`)
+		} else {
+			term, input, _ = strings.Cut(input, " ")
+		}
+
+		// a lone - or empty quotes are ignored
+		if term == "" {
+			continue
+		}
+
+		if excluded {
+			q.Excluded = append(q.Excluded, term)
+		} else {
+			q.Terms = append(q.Terms, term)
+		}
+	}
+
+	return q
+}
+
+// Returns the finders of the terms (or of the regex) that must match
+func (q Query) finders() []finder {
+	if q.Regex != nil {
+		return []finder{regex_finder(q.Regex)}
+	}
+
+	return terms_finders(q.Terms)
+}
+
+func terms_finders(terms []string) []finder {
+	var result []finder
+
+	for _, term := range terms {
+		folded_term := fold_string(term)
+		result = append(result, func(f *folded_text, words bool) (int, int) {
+			return f.find(folded_term, words)
+		})
+	}
+
+	return result
+}
+
+// Returns a finder of the first match of the regex in the original text, anywhere in it
+func regex_finder(re *regexp.Regexp) finder {
+	return func(f *folded_text, words bool) (int, int) {
+		if loc := re.FindStringIndex(f.source); loc != nil {
+			return loc[0], loc[1]
+		}
+		return -1, -1
+	}
+}
+
+func (q Query) matcher() query_matcher {
+	m := query_matcher{
+		query: q,
+		tag:   fold_string(q.Tag),
+	}
+
+	if q.Regex != nil {
+		m.regex = regex_finder(q.Regex)
+	}
+	for _, term := range q.Terms {
+		m.terms = append(m.terms, fold_string(term))
+	}
+	for _, term := range q.Excluded {
+		m.excluded = append(m.excluded, fold_string(term))
+	}
+
+	return m
+}
+
+// Returns true if the rule has the tag of the query, all its terms, and none of the excluded ones.
+// The rules with a MatchRegex do not match, see Filter.
+func (q Query) Matches(r *Rule, search_desc bool) bool {
+	return q.matcher().matches(r, search_desc)
+}
+
+func (m query_matcher) matches(r *Rule, search_desc bool) bool {
+	if r.MatchRegex != "" {
+		return false
+	}
+
+	text := r.search_text()
+
+	return m.matches_text(r, text.words, text.description.text, search_desc)
+}
+
+// Same as matches for a rule without MatchRegex, with its folded texts (see search_text)
+func (m query_matcher) matches_text(r *Rule, words string, description string, search_desc bool) bool {
+	if m.query.Error != nil {
+		return false
+	}
+
+	if m.tag != "" && !r.has_folded_tag(m.tag, m.query.TagComplete) {
+		return false
+	}
+
+	if m.regex != nil && !r.has_match(m.regex, search_desc) {
+		return false
+	}
+
+	for _, term := range m.terms {
+		if !has_term(words, description, term, search_desc) {
+			return false
+		}
+	}
+
+	for _, term := range m.excluded {
+		if has_term(words, description, term, search_desc) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the rules that match the query. The rules with a MatchRegex are
+// only returned when their regex matches the text of the query, their
+// captures are then used in the arguments. The rules taking arguments also
+// match their keyword followed by the arguments.
+func (q Query) Filter(rules []*Rule, search_desc bool) []*Rule {
+	return q.matcher().filter(rules, search_desc)
+}
+
+func (m query_matcher) filter(rules []*Rule, search_desc bool) []*Rule {
+	var result []*Rule
+
+	for _, rule := range rules {
+		if rule.MatchRegex != "" {
+			rule.captures = rule.regex_captures(m.query.Text)
+			if rule.captures != nil && (m.tag == "" || rule.has_folded_tag(m.tag, m.query.TagComplete)) {
+				result = append(result, rule)
+			}
+			continue
+		}
+
+		if rule.takes_args() {
+			args, ok := rule.keyword_args(m.query.Text)
+			rule.captures = map[string]string{"args": args}
+			if ok && (m.tag == "" || rule.has_folded_tag(m.tag, m.query.TagComplete)) {
+				result = append(result, rule)
+				continue
+			}
+		}
+
+		if m.matches(rule, search_desc) {
+			result = append(result, rule)
+		}
+	}
+
+	return result
+}
+
+// Returns true if the results of the query are part of the results of the
+// previous one: it has the same tag (or a longer one while it is typed), and each
+// term of the previous one is the start of one of its terms. The excluded terms
+// and the regex query mode can give more results, they are never narrower.
+func (q Query) narrows(previous Query) bool {
+	if previous.Regex != nil || previous.Error != nil || len(previous.Excluded) != 0 {
+		return false
+	}
+	if q.Regex != nil && len(previous.Terms) != 0 {
+		return false
+	}
+
+	if tag, previous_tag := fold_string(q.Tag), fold_string(previous.Tag); previous_tag != "" {
+		if previous.TagComplete && (!q.TagComplete || tag != previous_tag) {
+			return false
+		}
+		if !strings.HasPrefix(tag, previous_tag) {
+			return false
+		}
+	}
+
+	for _, previous_term := range previous.Terms {
+		previous_term = fold_string(previous_term)
+		if !slices.ContainsFunc(q.Terms, func(term string) bool {
+			return strings.HasPrefix(fold_string(term), previous_term)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// search_text is the text of a rule prepared for searching
+type search_text struct {
+	match       folded_text
+	aliases     []folded_text
+	tags        []folded_text
+	description folded_text
+	words       string // the folded match, aliases and tags, separated by new lines
+}
+
+// Returns the text of the rule prepared for searching, it is computed the first time.
+// The rule must not be modified after it.
+func (r *Rule) search_text() *search_text {
+	if r.search != nil {
+		return r.search
+	}
+
+	r.search = &search_text{
+		match:       fold_text(r.Match),
+		description: fold_text(r.Description),
+	}
+	for _, alias := range r.Aliases {
+		r.search.aliases = append(r.search.aliases, fold_text(alias))
+	}
+	for _, tag := range r.Tags {
+		r.search.tags = append(r.search.tags, fold_text(tag))
+	}
+
+	words := []string{r.search.match.text}
+	for _, text := range r.search.aliases {
+		words = append(words, text.text)
+	}
+	for _, text := range r.search.tags {
+		words = append(words, text.text)
+	}
+	r.search.words = strings.Join(words, "\n")
+
+	return r.search
+}
+
+// Returns true if the folded term starts a word of the words of a rule (match, aliases
+// and tags), or is in its description. It is the same as has_match, faster.
+func has_term(words string, description string, term string, search_desc bool) bool {
+	return index_term(words, term, true) != -1 ||
+		(search_desc && strings.Contains(description, term))
+}
+
+// Returns true if something is found at the start of a word of the match,
+// an alias or a tag, or in the description
+func (r *Rule) has_match(find finder, search_desc bool) bool {
+	text := r.search_text()
+
+	if start, _ := find(&text.match, true); start != -1 {
+		return true
+	}
+
+	if r.matching_alias(find) != -1 {
+		return true
+	}
+
+	for i := range text.tags {
+		if start, _ := find(&text.tags[i], true); start != -1 {
+			return true
+		}
+	}
+
+	if search_desc {
+		if start, _ := find(&text.description, false); start != -1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the index of the first alias where something is found, -1 if there is none
+func (r *Rule) matching_alias(find finder) int {
+	text := r.search_text()
+
+	for i := range text.aliases {
+		if start, _ := find(&text.aliases[i], true); start != -1 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Returns the position of the first occurrence of the term in s, ignoring case
+// and diacritics (see fold_text). With words, the term has to be at the start of a word.
+// Returns -1, -1 if it is not found.
+func find_term(s string, term string, words bool) (int, int) {
+	folded := fold_text(s)
+
+	return folded.find(fold_string(term), words)
+}
+
+func is_word_rune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// Splits the text in parts that alternate between highlighted and not highlighted,
+// starting with a highlighted one (that can be empty). The ranges are byte
+// positions [start, end) of the highlighted parts, they can overlap.
+// The last part is omitted if it is empty.
+func highlight_parts(text string, ranges [][2]int) []string {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	result := []string{}
+	position := 0
+
+	for i := 0; i < len(ranges); {
+		start, end := ranges[i][0], ranges[i][1]
+
+		// merge the ranges that overlap or touch this one
+		for i++; i < len(ranges) && ranges[i][0] <= end; i++ {
+			end = max(end, ranges[i][1])
+		}
+
+		if len(result) == 0 && start > 0 {
+			result = append(result, "")
+		}
+		if len(result) != 0 {
+			result = append(result, text[position:start])
+		}
+		result = append(result, text[start:end])
+		position = end
+	}
+
+	if len(result) == 0 {
+		result = append(result, "")
+	}
+	if position < len(text) {
+		result = append(result, text[position:])
+	}
+
+	return result
+}
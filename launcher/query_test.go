@@ -0,0 +1,138 @@
+package launcher
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  Query
+	}{
+		{"", Query{}},
+		{"open git", Query{Terms: []string{"open", "git"}, Text: "open git"}},
+		{"  open   git  ", Query{Terms: []string{"open", "git"}, Text: "open   git"}},
+		{`"open git" hub`, Query{Terms: []string{"open git", "hub"}, Text: `"open git" hub`}},
+		{`hub "open git`, Query{Terms: []string{"hub", "open git"}, Text: `hub "open git`}},
+		{`open -lab -"git hub"`, Query{Terms: []string{"open"}, Excluded: []string{"lab", "git hub"}, Text: `open -lab -"git hub"`}},
+		{`- "" -`, Query{Text: `- "" -`}},
+		{"#dev open", Query{Tag: "dev", TagComplete: true, Terms: []string{"open"}, Text: "open"}},
+		{"#de", Query{Tag: "de"}},
+		{"/", Query{Text: "/"}},
+		{"#dev /", Query{Tag: "dev", TagComplete: true, Text: "/"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseQuery(tt.input, "/"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("'%v': got %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	// regex query mode
+	if q := ParseQuery("re:^a.c", "re:"); q.Regex == nil || q.Regex.String() != "(?i)^a.c" || q.Error != nil || q.Terms != nil {
+		t.Errorf("got %+v, want a regex", q)
+	}
+	if q := ParseQuery("/(a", "/"); q.Regex != nil || q.Error == nil {
+		t.Errorf("got %+v, want an error", q)
+	}
+	if q := ParseQuery("/a", ""); q.Regex != nil || len(q.Terms) != 1 {
+		t.Errorf("got %+v, want no regex without sigil", q)
+	}
+}
+
+func TestRuleFilterTerms(t *testing.T) {
+	var rules = []*Rule{
+		{Match: "GH", Description: "Open github.com", Exe: "firefox.exe"},
+		{Match: "GL", Description: "Open gitlab.com", Exe: "firefox.exe"},
+		{Match: "Git Bash", Description: "Terminal", Aliases: []string{"shell"}, Tags: []string{"dev"}, Exe: "bash.exe"},
+	}
+
+	var tests = []struct {
+		input string
+		want  []string
+	}{
+		{"open git", []string{"GH", "GL"}},
+		{"git open", []string{"GH", "GL"}},
+		{"open hub", []string{"GH"}},
+		{"open -lab", []string{"GH"}},
+		{"git -open", []string{"Git Bash"}},
+		{"bash git", []string{"Git Bash"}},
+		{"ash", []string{}},
+		{"sh dev", []string{"Git Bash"}},
+		{`"open git"`, []string{"GH", "GL"}},
+		{`"git open"`, []string{}},
+		{`-"gitlab.com"`, []string{"GH", "Git Bash"}},
+		{"-", []string{"GH", "GL", "Git Bash"}},
+	}
+
+	for _, tt := range tests {
+		ans := RulesToAray(FilterRules(rules, tt.input, true))
+		sort.Strings(ans)
+		if len(ans) != len(tt.want) || (len(ans) != 0 && !reflect.DeepEqual(ans, tt.want)) {
+			t.Errorf("'%v': got %v, want %v", tt.input, ans, tt.want)
+		}
+	}
+
+	// every term is highlighted, the excluded ones are not
+	display := rules[0].GetDisplayStrings("hub open -lab", true)
+	want := []string{"", "GH - ", "Open", " git", "hub", ".com"}
+	if !reflect.DeepEqual(display, want) {
+		t.Errorf("got %q, want %q", display, want)
+	}
+}
+
+func TestHighlightParts(t *testing.T) {
+	var tests = []struct {
+		ranges [][2]int
+		want   []string
+	}{
+		{nil, []string{"", "abcdef"}},
+		{[][2]int{{0, 2}}, []string{"ab", "cdef"}},
+		{[][2]int{{4, 6}}, []string{"", "abcd", "ef"}},
+		{[][2]int{{4, 6}, {0, 1}}, []string{"a", "bcd", "ef"}},
+		{[][2]int{{1, 3}, {2, 4}, {4, 5}}, []string{"", "a", "bcde", "f"}},
+		{[][2]int{{0, 6}, {1, 2}}, []string{"abcdef"}},
+	}
+
+	for _, tt := range tests {
+		if got := highlight_parts("abcdef", tt.ranges); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %q, want %q", tt.ranges, got, tt.want)
+		}
+	}
+}
+
+func TestQueryRegexMode(t *testing.T) {
+	var rules = []*Rule{
+		{Match: "ex1", Description: "Example rule 1", Exe: "dummy.exe"},
+		{Match: "ex_noargs", Description: "Example without args", Exe: "dummy.exe"},
+		{Match: "notes", Description: "Edit my notes", Aliases: []string{"memo2"}, Exe: "code.exe"},
+	}
+
+	var tests = []struct {
+		input string
+		want  []string
+	}{
+		{"/", []string{"ex1", "ex_noargs", "notes"}},
+		{`/^ex\d`, []string{"ex1"}},
+		{`/^EX`, []string{"ex1", "ex_noargs"}},
+		{`/(?-i)^EX`, []string{}},
+		{`/\d$`, []string{"ex1", "notes"}},
+		{`/without|edit`, []string{"ex_noargs", "notes"}},
+		{`/(`, []string{}},
+	}
+
+	for _, tt := range tests {
+		ans := RulesToAray(ParseQuery(tt.input, "/").Filter(rules, true))
+		if len(ans) != len(tt.want) || (len(ans) != 0 && !reflect.DeepEqual(ans, tt.want)) {
+			t.Errorf("'%v': got %v, want %v", tt.input, ans, tt.want)
+		}
+	}
+
+	display := rules[1].DisplayStrings(ParseQuery(`/x_|out`, "/"), true)
+	want := []string{"", "e", "x_", "noargs - Example with", "out", " args"}
+	if !reflect.DeepEqual(display, want) {
+		t.Errorf("got %q, want %q", display, want)
+	}
+}
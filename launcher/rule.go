@@ -1,28 +1,66 @@
 package launcher
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"regexp"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Rule struct {
 	Match       string
+	MatchRegex  string `toml:",omitempty"` // the rule is only shown when the input matches this regex
 	Description string
+	Aliases     []string `toml:",omitempty"` // other names the rule can be found by
+	Tags        []string `toml:",omitempty"` // keywords, a query starting with #tag only shows the rules with the tag
 	Exe         string
 	Args        []string
+	Snippet     string `toml:",omitempty"` // text copied to the clipboard instead of running Exe (see ExpandSnippet)
+	WorkDir     string `toml:",omitempty"` // working directory of the program, the launcher one if empty
+	Icon        string `toml:",omitempty"` // PNG file, icon theme name or built-in icon
 	LastUse     time.Time
+	UseCount    int `toml:",omitempty"`
+
+	preview func() []string // richer preview given by the provider of the rule
+	execute func()          // done instead of running Exe, given by the provider of the rule
+	used    func()          // called after the rule is executed (eg: to remember its use)
+	actions []Action        // other actions given by the provider of the rule
+	section string          // section of the result list, SECTION_RULES if empty
+	score   float64         // relevance given by the provider of the rule (eg: a script), 0 if none
+
+	regex    *regexp.Regexp    // compiled MatchRegex (by Check)
+	captures map[string]string // groups of MatchRegex captured from the input, by number and name
+	search   *search_text      // texts prepared for searching, computed once
 }
 
 func (r *Rule) Execute() {
 	r.LastUse = time.Now()
+	r.UseCount++
 
-	cmd := exec.Command(r.Exe, r.Args...)
+	if r.used != nil {
+		r.used()
+	}
+
+	if r.execute != nil {
+		r.execute()
+		return
+	}
+
+	if r.Snippet != "" {
+		system_clipboard.Set(r.ExpandSnippet(time.Now(), system_clipboard.Get))
+		return
+	}
+
+	exe, args, dir := r.Command()
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = dir
 
 	err := cmd.Start()
 	if err != nil {
@@ -30,135 +68,337 @@ func (r *Rule) Execute() {
 	}
 }
 
-func (r *Rule) Check() error {
-	if len(r.Match) == 0 {
-		return errors.New("invalid rule, Match field is empty")
+// Action is something that can be done with a rule.
+// They are listed in the action menu, the first one is the default.
+type Action struct {
+	Name     string
+	Run      func()
+	KeepOpen bool // do not close the launcher after running the action
+	Confirm  bool // ask a confirmation before running the action (eg: killing a process)
+}
+
+func (r *Rule) Actions() []Action {
+	actions := []Action{
+		{Name: "Execute", Run: r.Execute},
+		{Name: "Execute and keep open", Run: r.Execute, KeepOpen: true},
 	}
-	if len(r.Description) == 0 {
-		return errors.New("invalid rule, Description field is empty")
+
+	return append(actions, r.actions...)
+}
+
+// Returns the program, arguments and working directory of the rule,
+// with the environment variables (written ${NAME}) replaced by their value
+func (r *Rule) Command() (string, []string, string) {
+	var args []string
+
+	for _, arg := range r.Args {
+		args = append(args, expand_env(r.expand_captures(arg)))
 	}
-	if len(r.Exe) == 0 {
-		return errors.New("invalid rule, Exe field is empty")
+
+	return expand_env(r.Exe), args, expand_env(r.WorkDir)
+}
+
+// Returns the command executed by the rule as a single line,
+// the arguments containing spaces or quotes are quoted
+func (r *Rule) CommandLine() string {
+	exe, args, _ := r.Command()
+
+	parts := []string{quote_arg(exe)}
+	for _, arg := range args {
+		parts = append(parts, quote_arg(arg))
 	}
 
-	return nil
+	return strings.Join(parts, " ")
 }
 
-// This function is to get data do display in the UI.
-// The given rule is split using the input in order to check
-// what part of the rule has been matched with the input.
-// The result list is of variable size and contains a list of strings
-// where any odd index contains a string that matched with input
-// and any even index a string that did not.
-// Example : ["match", "not match", "match"]
-func (r *Rule) GetDisplayStrings(input string, search_desc bool) []string {
-	result := []string{}
-	var tmp string
+// Replaces the environment variables written ${NAME} by their value,
+// the unknown ones are kept as they are
+func expand_env(in string) string {
+	var result strings.Builder
 
-	// if the input is empty, return ["", all_the_text]
-	if input == "" {
-		result = append(result, "")
-		result = append(result, fmt.Sprintf("%v - %v", r.Match, r.Description))
-		return result
+	for {
+		start := strings.Index(in, "${")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(in[start:], "}")
+		if end == -1 {
+			break
+		}
+		end += start
+
+		result.WriteString(in[:start])
+		if value, ok := os.LookupEnv(in[start+2 : end]); ok {
+			result.WriteString(value)
+		} else {
+			result.WriteString(in[start : end+1])
+		}
+
+		in = in[end+1:]
 	}
 
-	quoted_input := regexp.QuoteMeta(input)
-	rule_match := fmt.Sprintf("^(?i)(%v)(.*)", quoted_input)
-	rule_desc := fmt.Sprintf("^(?i)(.*)(%v)(.*)", quoted_input)
+	result.WriteString(in)
 
-	re_match := regexp.MustCompile(rule_match)
-	re_desc := regexp.MustCompile(rule_desc)
+	return result.String()
+}
 
-	res_match := re_match.FindStringSubmatch(r.Match)
+// Replaces the groups captured by MatchRegex, written {1} or {name} ({0} is the
+// whole match). The unknown ones are kept as they are.
+func (r *Rule) expand_captures(in string) string {
+	for name, value := range r.captures {
+		in = strings.ReplaceAll(in, "{"+name+"}", value)
+	}
 
-	if len(res_match) == 0 {
-		// if there is no match in the Match part of the rule
+	return in
+}
 
-		// insert an empty string for the match
-		result = append(result, "")
-		// and put all the rule match in a tmp string
-		tmp = r.Match
+// Returns the groups captured when the MatchRegex of the rule matches the text,
+// nil if it does not match
+func (r *Rule) regex_captures(text string) map[string]string {
+	if r.regex == nil {
+		return nil
+	}
 
-	} else if len(res_match) == 3 {
-		// if there is a match, there should be 3 elements
+	match := r.regex.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
 
-		// insert the part that matched (index 0 is the whole rule)
-		result = append(result, res_match[1])
-		// and put the part that did not match in the tmp string
-		tmp = res_match[2]
+	captures := map[string]string{}
+	for i, name := range r.regex.SubexpNames() {
+		captures[strconv.Itoa(i)] = match[i]
+		if name != "" {
+			captures[name] = match[i]
+		}
+	}
 
-	} else {
-		// this should not happen ... panic
-		log.Panicf("error while parsing Match of rule %v", r)
+	return captures
+}
+
+func quote_arg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'") {
+		return arg
 	}
 
-	// Add the separator to the tmp string
-	tmp += " - "
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
 
-	// If description search is enabled, search in it
-	if search_desc {
-		res_desc := re_desc.FindStringSubmatch(r.Description)
+// Checks the rule itself, see CheckAliases for the collisions with the other rules
+func (r *Rule) Check() error {
+	if len(r.Match) == 0 {
+		return errors.New("invalid rule, Match field is empty")
+	}
+	if len(r.Description) == 0 {
+		return errors.New("invalid rule, Description field is empty")
+	}
+	if len(r.Exe) == 0 && len(r.Snippet) == 0 {
+		return errors.New("invalid rule, Exe field is empty")
+	}
+	if len(r.Exe) != 0 && len(r.Snippet) != 0 {
+		return errors.New("invalid rule, a snippet can not have an Exe")
+	}
 
-		if len(res_desc) == 0 {
-			// if there is no match in the Description part of the rule
+	// the regex is compiled once, here
+	if r.MatchRegex != "" {
+		regex, err := regexp.Compile(r.MatchRegex)
+		if err != nil {
+			return fmt.Errorf("invalid rule, MatchRegex is not valid: %w", err)
+		}
+		r.regex = regex
+	}
 
-			// insert an the whole description with the tmp string
-			result = append(result, tmp+r.Description)
+	for _, tag := range r.Tags {
+		if tag == "" || strings.ContainsAny(tag, " \t") {
+			return fmt.Errorf("invalid rule, tag '%v' is empty or contains spaces", tag)
+		}
+	}
 
-		} else if len(res_desc) == 4 {
-			// if there is a match, there should be 4 elements
+	for i, alias := range r.Aliases {
+		if alias == "" {
+			return errors.New("invalid rule, an alias is empty")
+		}
+		if strings.EqualFold(alias, r.Match) || slices.ContainsFunc(r.Aliases[:i], func(s string) bool { return strings.EqualFold(s, alias) }) {
+			return fmt.Errorf("invalid rule, alias '%v' is defined twice", alias)
+		}
+	}
 
-			// insert the first element (not matched) with the tmp string
-			result = append(result, tmp+res_desc[1])
+	return nil
+}
 
-			// then insert the part that matched
-			result = append(result, res_desc[2])
+// Returns the error of each rule (nil if valid) having an alias that is also
+// the Match or an alias of another rule, ignoring case and diacritics
+func CheckAliases(rules []*Rule) []error {
+	// the rules having each name, as Match or alias
+	owners := map[string][]*Rule{}
+	add := func(name string, rule *Rule) {
+		name = fold_string(name)
+		if list := owners[name]; len(list) == 0 || list[len(list)-1] != rule { // the names of a rule are added together
+			owners[name] = append(owners[name], rule)
+		}
+	}
+	for _, rule := range rules {
+		add(rule.Match, rule)
+		for _, alias := range rule.Aliases {
+			add(alias, rule)
+		}
+	}
 
-			// finaly the remaining part that did not match (only if it is not empty)
-			if len(res_desc[3]) > 0 {
-				result = append(result, res_desc[3])
+	errs := make([]error, len(rules))
+	for i, rule := range rules {
+		for _, alias := range rule.Aliases {
+			j := slices.IndexFunc(owners[fold_string(alias)], func(other *Rule) bool { return other != rule })
+			if j != -1 {
+				errs[i] = fmt.Errorf("invalid rule, alias '%v' collides with rule '%v'", alias, owners[fold_string(alias)][j].Match)
+				break
 			}
+		}
+	}
 
-		} else {
-			// this should not happen ... panic
-			log.Panicf("error while parsing Match of rule %v", r)
+	return errs
+}
+
+// Returns the tag of a query starting with #tag and the rest of the query.
+// The tag is complete when it is followed by a space, otherwise it is being typed.
+// A query without tag is returned as it is.
+func split_tag_query(input string) (tag string, query string, complete bool) {
+	if !strings.HasPrefix(input, "#") {
+		return "", input, false
+	}
+
+	tag, query, complete = strings.Cut(input[1:], " ")
+
+	return tag, strings.TrimLeft(query, " "), complete
+}
+
+// Returns true if the rule has the tag (or a tag starting with it, if not complete),
+// ignoring case and diacritics
+func (r *Rule) has_tag(tag string, complete bool) bool {
+	return r.has_folded_tag(fold_string(tag), complete)
+}
+
+func (r *Rule) has_folded_tag(tag string, complete bool) bool {
+	for _, t := range r.search_text().tags {
+		if t.text == tag || (!complete && strings.HasPrefix(t.text, tag)) {
+			return true
 		}
-	} else {
-		// otherwise, just put the description in the result
-		result = append(result, tmp+r.Description)
 	}
 
-	return result
+	return false
 }
 
-func FilterRules(rules []*Rule, input string, search_desc bool) []*Rule {
-	var result []*Rule
+// This function is to get data do display in the UI.
+// The given rule is split using the input in order to check
+// what part of the rule has been matched with the terms of the input.
+// The result list is of variable size and contains a list of strings
+// where any even index contains a string that matched with input
+// and any odd index a string that did not.
+// Example : ["match", "not match", "match"]
+func (r *Rule) GetDisplayStrings(input string, search_desc bool) []string {
+	return r.DisplayStrings(ParseQuery(input, ""), search_desc)
+}
 
-	lower_input := strings.ToLower(input)
-	var lower_match string
-	var lower_desc string
+// Same as GetDisplayStrings, with a parsed query.
+// When an alias matched a term that is not in Match, it is displayed after Match.
+// The captures of MatchRegex are replaced in the description.
+func (r *Rule) DisplayStrings(query Query, search_desc bool) []string {
+	var ranges [][2]int
+	text := r.search_text()
+	display := r.Match
+	finders := query.finders()
+
+	// the first occurrence of each term in Match, and the terms that are not in it
+	var missing []finder
+	for _, find := range finders {
+		if start, end := find(&text.match, true); start != -1 {
+			ranges = append(ranges, [2]int{start, end})
+		} else {
+			missing = append(missing, find)
+		}
+	}
 
-	for _, rule := range rules {
-		lower_match = strings.ToLower(rule.Match)
-		lower_desc = strings.ToLower(rule.Description)
+	// the alias that matched one of the missing terms
+	alias := -1
+	for i := 0; i < len(missing) && alias == -1; i++ {
+		alias = r.matching_alias(missing[i])
+	}
+	if alias != -1 {
+		display += " ("
+		for _, find := range finders {
+			if start, end := find(&text.aliases[alias], true); start != -1 {
+				ranges = append(ranges, [2]int{len(display) + start, len(display) + end})
+			}
+		}
+		display += r.Aliases[alias] + ")"
+	}
 
-		// Check if lower_match starts with lower_input
-		// both strings are lowered to ignore case
-		// if input is an empty string, it will always match
-		if strings.HasPrefix(lower_match, lower_input) ||
-			(strings.Contains(lower_desc, lower_input) && search_desc) {
-			result = append(result, rule)
+	display += " - "
+
+	// the description of a MatchRegex rule can contain captures
+	description := text.description
+	if r.captures != nil {
+		description = fold_text(r.expand_captures(r.Description))
+	}
+
+	// If description search is enabled, search in it
+	if search_desc {
+		for _, find := range finders {
+			if start, end := find(&description, false); start != -1 {
+				ranges = append(ranges, [2]int{len(display) + start, len(display) + end})
+			}
 		}
 	}
+	display += description.source
 
-	return result
+	return highlight_parts(display, ranges)
 }
 
+// Returns the rules that match the query of the input (see ParseQuery)
+func FilterRules(rules []*Rule, input string, search_desc bool) []*Rule {
+	return ParseQuery(input, "").Filter(rules, search_desc)
+}
+
+// Sorts the rules by the score given by their provider (the highest first),
+// then by last use. The order of the rules with the same score and last use is kept.
 func SortRules(rules []*Rule) {
+	before := func(a, b *Rule) bool {
+		return a.score > b.score || a.score == b.score && a.LastUse.After(b.LastUse)
+	}
+
+	// the results of the index are often sorted already
+	sorted := true
+	for i := 1; i < len(rules) && sorted; i++ {
+		sorted = !before(rules[i], rules[i-1])
+	}
+	if sorted {
+		return
+	}
+
+	// the keys are copied, sorting the pointers to the rules is slow with many results
+	type sort_key struct {
+		score    float64
+		last_use time.Time
+		position int // the sort is stable
+		rule     *Rule
+	}
+
+	keys := make([]sort_key, len(rules))
+	for i, rule := range rules {
+		keys[i] = sort_key{rule.score, rule.LastUse, i, rule}
+	}
 
-	sort.Slice(rules, func(i, j int) bool {
-		return rules[i].LastUse.After(rules[j].LastUse)
+	slices.SortFunc(keys, func(a, b sort_key) int {
+		if a.score != b.score {
+			return cmp.Compare(b.score, a.score)
+		}
+		if c := b.last_use.Compare(a.last_use); c != 0 {
+			return c
+		}
+		return a.position - b.position
 	})
+
+	for i, key := range keys {
+		rules[i] = key.rule
+	}
 }
 
 func RulesToAray(rules []*Rule) []string {
@@ -3,6 +3,7 @@ package launcher
 import (
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
@@ -12,10 +13,10 @@ import (
 func TestRuleGetDisplayStrings(t *testing.T) {
 	rules := []*Rule{
 		// regular case
-		{"Demo rule", "Description", "dummy.exe", nil, time.Unix(0, 0)},
+		{Match: "Demo rule", Description: "Description", Exe: "dummy.exe", LastUse: time.Unix(0, 0)},
 
 		// edge case (regexp metacharacters)
-		{"Edge rule", "{}[]()^$.|*+?", "dummy.exe", nil, time.Unix(0, 0)},
+		{Match: "Edge rule", Description: "{}[]()^$.|*+?", Exe: "dummy.exe", LastUse: time.Unix(0, 0)},
 	}
 
 	var tests = []struct {
@@ -26,8 +27,11 @@ func TestRuleGetDisplayStrings(t *testing.T) {
 	}{
 		{"empty", rules[0], "", []string{"", "Demo rule - Description"}},
 		{"match 1", rules[0], "demo", []string{"Demo", " rule - Description"}},
-		{"match 2", rules[0], "Demo ", []string{"Demo ", "rule - Description"}},
-		{"match 3", rules[0], "Demo rule", []string{"Demo rule", " - Description"}},
+		{"match 2", rules[0], "Demo ", []string{"Demo", " rule - Description"}},
+		{"match 3", rules[0], "Demo rule", []string{"Demo", " ", "rule", " - Description"}},
+		{"match 4", rules[0], "rule demo", []string{"Demo", " ", "rule", " - Description"}},
+		{"phrase", rules[0], "\"Demo rule\"", []string{"Demo rule", " - Description"}},
+		{"phrase 2", rules[0], "\"Demo r", []string{"Demo r", "ule - Description"}},
 		{"desc 1", rules[0], "Des", []string{"", "Demo rule - ", "Des", "cription"}},
 		{"desc 2", rules[0], "crip", []string{"", "Demo rule - Des", "crip", "tion"}},
 		{"desc 3", rules[0], "tion", []string{"", "Demo rule - Descrip", "tion"}},
@@ -81,7 +85,7 @@ func TestRuleGetDisplayStrings(t *testing.T) {
 func TestRuleFilterNoCopy(t *testing.T) {
 	// Create a rule list
 	var rules1 = []*Rule{
-		{"xxx", "ChangeMe", "dummy.exe", nil, time.Unix(0, 0)},
+		{Match: "xxx", Description: "ChangeMe", Exe: "dummy.exe", LastUse: time.Unix(0, 0)},
 	}
 
 	// This function should not interfere
@@ -106,9 +110,9 @@ func TestRuleFilterNoCopy(t *testing.T) {
 
 func TestRuleFilter(t *testing.T) {
 	var rules = []*Rule{
-		{"Demo 1", "Description 1", "dummy.exe", nil, time.Unix(0, 0)},
-		{"demo 2", "Description 2", "dummy.exe", nil, time.Unix(0, 0)},
-		{"r/(a-z)+", "Sub test 1", "dummy.exe", nil, time.Unix(0, 0)},
+		{Match: "Demo 1", Description: "Description 1", Exe: "dummy.exe", LastUse: time.Unix(0, 0)},
+		{Match: "demo 2", Description: "Description 2", Exe: "dummy.exe", LastUse: time.Unix(0, 0)},
+		{Match: "r/(a-z)+", Description: "Sub test 1", Exe: "dummy.exe", LastUse: time.Unix(0, 0)},
 	}
 
 	var tests = []struct {
@@ -153,3 +157,233 @@ func TestRuleFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleCommandLine(t *testing.T) {
+	var tests = []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{"no args", Rule{Exe: "dummy.exe"}, "dummy.exe"},
+		{"args", Rule{Exe: "dummy.exe", Args: []string{"-a", "b"}}, "dummy.exe -a b"},
+		{"spaces", Rule{Exe: "C:\\Program Files\\dummy.exe", Args: []string{"arg 1", ""}}, "\"C:\\Program Files\\dummy.exe\" \"arg 1\" \"\""},
+		{"quotes", Rule{Exe: "wt", Args: []string{"python.exe 'script.py'", "say \"hi\""}}, "wt \"python.exe 'script.py'\" \"say \\\"hi\\\"\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ans := tt.rule.CommandLine(); ans != tt.want {
+				t.Errorf("got '%v', want '%v'", ans, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleExpandEnv(t *testing.T) {
+	t.Setenv("LAUNCHER_TEST_DIR", "C:\\Users\\me")
+	t.Setenv("LAUNCHER_TEST_EMPTY", "")
+
+	var tests = []struct {
+		input string
+		want  string
+	}{
+		{"no variable", "no variable"},
+		{"${LAUNCHER_TEST_DIR}\\Desktop", "C:\\Users\\me\\Desktop"},
+		{"${LAUNCHER_TEST_DIR}/${LAUNCHER_TEST_DIR}", "C:\\Users\\me/C:\\Users\\me"},
+		{"[${LAUNCHER_TEST_EMPTY}]", "[]"},
+		{"${LAUNCHER_TEST_UNKNOWN}/x", "${LAUNCHER_TEST_UNKNOWN}/x"},
+		{"$LAUNCHER_TEST_DIR %LAUNCHER_TEST_DIR%", "$LAUNCHER_TEST_DIR %LAUNCHER_TEST_DIR%"},
+		{"${}", "${}"},
+		{"${LAUNCHER_TEST_DIR", "${LAUNCHER_TEST_DIR"},
+	}
+
+	for _, tt := range tests {
+		if got := expand_env(tt.input); got != tt.want {
+			t.Errorf("%v: got '%v', want '%v'", tt.input, got, tt.want)
+		}
+	}
+
+	rule := Rule{Exe: "${LAUNCHER_TEST_DIR}\\app.exe", Args: []string{"--dir", "${LAUNCHER_TEST_DIR}"}, WorkDir: "${LAUNCHER_TEST_DIR}"}
+	exe, args, dir := rule.Command()
+	if exe != "C:\\Users\\me\\app.exe" || !reflect.DeepEqual(args, []string{"--dir", "C:\\Users\\me"}) || dir != "C:\\Users\\me" {
+		t.Errorf("got %v %v %v", exe, args, dir)
+	}
+	if rule.Args[1] != "${LAUNCHER_TEST_DIR}" {
+		t.Error("the rule should not be modified")
+	}
+}
+
+func TestRuleAliasesAndTags(t *testing.T) {
+	var rules = []*Rule{
+		{Match: "Visual Studio Code", Description: "Editor", Aliases: []string{"vscode", "code"}, Tags: []string{"dev", "editor"}, Exe: "code.exe"},
+		{Match: "Notepad", Description: "Text editor", Aliases: []string{"np"}, Tags: []string{"editor"}, Exe: "notepad.exe"},
+		{Match: "Calculator", Description: "Maths", Tags: []string{"Tools"}, Exe: "calc.exe"},
+	}
+
+	var filter_tests = []struct {
+		input string
+		want  []string
+	}{
+		{"vs", []string{"Visual Studio Code"}},
+		{"VSC", []string{"Visual Studio Code"}},
+		{"co", []string{"Visual Studio Code"}},
+		{"np", []string{"Notepad"}},
+		{"edit", []string{"Notepad", "Visual Studio Code"}},
+		{"tool", []string{"Calculator"}},
+		{"#editor", []string{"Notepad", "Visual Studio Code"}},
+		{"#ed", []string{"Notepad", "Visual Studio Code"}},
+		{"#ed ", []string{}},
+		{"#tools", []string{"Calculator"}},
+		{"#editor n", []string{"Notepad"}},
+		{"#editor  code", []string{"Visual Studio Code"}},
+		{"#dev np", []string{}},
+		{"#", []string{"Calculator", "Notepad", "Visual Studio Code"}},
+	}
+
+	for _, tt := range filter_tests {
+		ans := RulesToAray(FilterRules(rules, tt.input, false))
+		sort.Strings(ans)
+		if len(ans) != len(tt.want) || (len(ans) != 0 && !reflect.DeepEqual(ans, tt.want)) {
+			t.Errorf("filter '%v': got %v, want %v", tt.input, ans, tt.want)
+		}
+	}
+
+	var display_tests = []struct {
+		rule  *Rule
+		input string
+		want  []string
+	}{
+		{rules[0], "vis", []string{"Vis", "ual Studio Code - Editor"}},
+		{rules[0], "vsc", []string{"", "Visual Studio Code (", "vsc", "ode) - Editor"}},
+		{rules[0], "#dev vsc", []string{"", "Visual Studio Code (", "vsc", "ode) - Editor"}},
+		{rules[0], "#dev co", []string{"", "Visual Studio ", "Co", "de - Editor"}},
+		{rules[0], "#dev ", []string{"", "Visual Studio Code - Editor"}},
+		{rules[1], "NP", []string{"", "Notepad (", "np", ") - Text editor"}},
+	}
+
+	for _, tt := range display_tests {
+		if ans := tt.rule.GetDisplayStrings(tt.input, false); !reflect.DeepEqual(ans, tt.want) {
+			t.Errorf("display '%v': got %q, want %q", tt.input, ans, tt.want)
+		}
+	}
+}
+
+func TestRuleCheckAliases(t *testing.T) {
+	rule := func(match string, aliases ...string) *Rule {
+		return &Rule{Match: match, Description: "Description", Exe: "dummy.exe", Aliases: aliases}
+	}
+
+	var tests = []struct {
+		name   string
+		rules  []*Rule
+		errors []bool // expected error for each rule
+	}{
+		{"valid", []*Rule{rule("a", "b", "c"), rule("d", "e")}, []bool{false, false}},
+		{"alias of another", []*Rule{rule("a", "b"), rule("c", "B")}, []bool{true, true}},
+		{"diacritics", []*Rule{rule("a", "école"), rule("Ecole")}, []bool{true, false}},
+		{"match of another", []*Rule{rule("a", "b"), rule("B")}, []bool{true, false}},
+		{"same match", []*Rule{rule("a"), rule("a")}, []bool{false, false}},
+		{"twice", []*Rule{rule("a", "b", "b")}, []bool{true}},
+		{"own match", []*Rule{rule("a", "A")}, []bool{true}},
+		{"empty", []*Rule{rule("a", "")}, []bool{true}},
+	}
+
+	for _, tt := range tests {
+		collisions := CheckAliases(tt.rules)
+		for i, r := range tt.rules {
+			err := r.Check()
+			if err == nil {
+				err = collisions[i]
+			}
+			if (err != nil) != tt.errors[i] {
+				t.Errorf("%v: rule %d got error %v, want %v", tt.name, i, err, tt.errors[i])
+			}
+		}
+	}
+
+	tags := &Rule{Match: "a", Description: "Description", Exe: "dummy.exe", Tags: []string{"two words"}}
+	if err := tags.Check(); err == nil {
+		t.Error("a tag with a space should be invalid")
+	}
+}
+
+func TestRuleMatchRegex(t *testing.T) {
+	var rules = []*Rule{
+		{Match: "Jira", MatchRegex: `^(?i)jira-(\d+)$`, Description: "Open ticket JIRA-{1}", Exe: "firefox.exe", Args: []string{"https://jira.example.com/browse/JIRA-{1}"}},
+		{Match: "Ping", MatchRegex: `^(?P<ip>\d+\.\d+\.\d+\.\d+)$`, Description: "Ping {ip}", Tags: []string{"net"}, Exe: "ping", Args: []string{"{ip}", "{unknown}"}},
+		{Match: "Jira board", Description: "Open the board", Exe: "firefox.exe"},
+	}
+
+	for _, rule := range rules {
+		if err := rule.Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		input string
+		want  []string
+	}{
+		{"jira", []string{"Jira board"}},
+		{"JIRA-1234", []string{"Jira"}},
+		{"jira-12x", []string{}},
+		{"192.168.1.10", []string{"Ping"}},
+		{"#net 10.0.0.1", []string{"Ping"}},
+		{"#dev 10.0.0.1", []string{}},
+	}
+
+	for _, tt := range tests {
+		ans := RulesToAray(FilterRules(rules, tt.input, true))
+		if len(ans) != len(tt.want) || (len(ans) != 0 && !reflect.DeepEqual(ans, tt.want)) {
+			t.Errorf("'%v': got %v, want %v", tt.input, ans, tt.want)
+		}
+	}
+
+	// the captures of the last filtering are used
+	FilterRules(rules, " jira-42 ", true)
+	if _, args, _ := rules[0].Command(); !reflect.DeepEqual(args, []string{"https://jira.example.com/browse/JIRA-42"}) {
+		t.Errorf("got %v", args)
+	}
+	if got := rules[0].GetDisplayStrings("jira-42", false); !reflect.DeepEqual(got, []string{"", "Jira - Open ticket JIRA-42"}) {
+		t.Errorf("got %q", got)
+	}
+
+	FilterRules(rules, "10.0.0.1", true)
+	if _, args, _ := rules[1].Command(); !reflect.DeepEqual(args, []string{"10.0.0.1", "{unknown}"}) {
+		t.Errorf("got %v", args)
+	}
+
+	// invalid regex
+	invalid := &Rule{Match: "a", MatchRegex: "(", Description: "Description", Exe: "dummy.exe"}
+	if err := invalid.Check(); err == nil {
+		t.Error("an invalid MatchRegex should be reported")
+	}
+}
+
+func TestSortRules(t *testing.T) {
+	rules := []*Rule{
+		{Match: "old", LastUse: time.Unix(100, 0)},
+		{Match: "script low", score: 1},
+		{Match: "recent", LastUse: time.Unix(200, 0)},
+		{Match: "script high", score: 2},
+		{Match: "script used", score: 1, LastUse: time.Unix(50, 0)},
+		{Match: "never"},
+	}
+
+	// the score of the providers first, then the last use
+	SortRules(rules)
+	want := []string{"script high", "script used", "script low", "recent", "old", "never"}
+	if got := RulesToAray(rules); !slices.Equal(got, want) {
+		t.Errorf("sorted rules = %v, want %v", got, want)
+	}
+}
+
+// The aliases of all the rules are checked at start
+func BenchmarkCheckAliases20k(b *testing.B) {
+	rules := generate_rules(20_000)
+
+	b.ResetTimer()
+	for range b.N {
+		CheckAliases(rules)
+	}
+}
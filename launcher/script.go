@@ -0,0 +1,347 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+const SCRIPT_TIMEOUT = 1000       // default time given to a script to answer a query, in milliseconds
+const SCRIPT_CLOSE_TIMEOUT = 1000 // time given to a script to exit when the launcher closes, in milliseconds
+
+// ScriptProvider gives the results of an external program, that can be written
+// in any language. The program is started once, and they exchange JSON lines:
+//
+//	-> {"id": 1, "query": "text"}
+//	<- {"id": 1, "results": [{"title": "...", "subtitle": "...", "exe": "...", "args": [], "icon": "...", "score": 1, "data": ...}]}
+//	-> {"activate": {"title": "...", "data": ...}}
+//
+// The answer must have the id of the query, the late answers are ignored.
+// A result without exe is sent back to the program with an activate message
+// when it is selected. What the program writes on stderr goes to the log.
+// The program is restarted when it crashes or does not answer in time.
+// When the launcher closes, the stdin of the program is closed: it should
+// exit after handling the last messages, or it is killed after a short time.
+type ScriptProvider struct {
+	name    string
+	exe     string
+	args    []string
+	timeout time.Duration
+
+	lock    sync.Mutex      // for the fields below
+	process *script_process // nil if the program is not running
+	last_id int
+}
+
+// Settings of a ScriptProvider in the config file
+type ScriptConfig struct {
+	Name    string   // section of the results
+	Exe     string   // program to run
+	Args    []string `toml:",omitempty"`
+	Timeout int32    `toml:",omitempty"` // in milliseconds, SCRIPT_TIMEOUT if 0
+}
+
+// Result of the program
+type ScriptItem struct {
+	Title    string          `json:"title"`
+	Subtitle string          `json:"subtitle,omitempty"`
+	Exe      string          `json:"exe,omitempty"`
+	Args     []string        `json:"args,omitempty"`
+	Icon     string          `json:"icon,omitempty"`
+	Score    float64         `json:"score,omitempty"` // the highest is first
+	Data     json.RawMessage `json:"data,omitempty"`  // sent back with the item when activated
+}
+
+// Messages sent to the program
+type script_request struct {
+	Id       int         `json:"id,omitempty"`
+	Query    *string     `json:"query,omitempty"`
+	Activate *ScriptItem `json:"activate,omitempty"`
+}
+
+// Messages received from the program
+type script_response struct {
+	Id      int          `json:"id"`
+	Results []ScriptItem `json:"results"`
+}
+
+// A running program
+type script_process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	done  chan struct{} // closed when the program has exited
+
+	lock    sync.Mutex                // for the fields below
+	waiting map[int]chan []ScriptItem // answers expected, by query id
+}
+
+func NewScriptProvider(config ScriptConfig) *ScriptProvider {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = SCRIPT_TIMEOUT
+	}
+
+	return &ScriptProvider{
+		name:    config.Name,
+		exe:     config.Exe,
+		args:    config.Args,
+		timeout: time.Duration(timeout) * time.Millisecond,
+	}
+}
+
+// Checks the settings of a script provider
+func (config ScriptConfig) Check() error {
+	if config.Name == "" {
+		return errors.New("invalid script, Name field is empty")
+	}
+	if config.Exe == "" {
+		return fmt.Errorf("invalid script '%v', Exe field is empty", config.Name)
+	}
+	if config.Timeout < 0 {
+		return fmt.Errorf("invalid script '%v', Timeout must be positive (or 0 for the default)", config.Name)
+	}
+
+	return nil
+}
+
+func (p *ScriptProvider) Name() string {
+	return p.name
+}
+
+func (p *ScriptProvider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	p.lock.Lock()
+	process, err := p.start()
+	p.last_id++
+	id := p.last_id
+	p.lock.Unlock()
+
+	if err != nil {
+		log.Printf("Script %v: %v\n", p.name, err)
+		return
+	}
+
+	text := query.Text
+	answer := process.expect(id)
+	defer process.forget(id)
+
+	if err := process.send(script_request{Id: id, Query: &text}); err != nil {
+		log.Printf("Script %v: %v\n", p.name, err)
+		p.stop(process)
+		return
+	}
+
+	select {
+	case items := <-answer:
+		SendResults(ctx, results, p.rules(items))
+	case <-process.done:
+		log.Printf("Script %v: the program has exited, it will be restarted\n", p.name)
+		p.stop(process)
+	case <-time.After(p.timeout):
+		log.Printf("Script %v: no answer after %v, the program will be restarted\n", p.name, p.timeout)
+		p.stop(process)
+	case <-ctx.Done():
+	}
+}
+
+// Sends an item back to the program
+func (p *ScriptProvider) Activate(item ScriptItem) {
+	p.lock.Lock()
+	process, err := p.start()
+	p.lock.Unlock()
+
+	if err == nil {
+		err = process.send(script_request{Activate: &item})
+	}
+	if err != nil {
+		log.Printf("Script %v: %v\n", p.name, err)
+	}
+}
+
+// Returns the rules of the items, sorted by score
+func (p *ScriptProvider) rules(items []ScriptItem) []*Rule {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+
+	var rules []*Rule
+	for _, item := range items {
+		rule := &Rule{
+			Match:       item.Title,
+			Description: item.Subtitle,
+			Exe:         item.Exe,
+			Args:        item.Args,
+			Icon:        item.Icon,
+			score:       item.Score,
+		}
+
+		// the items without program are for the script
+		if item.Exe == "" {
+			rule.execute = func() { p.Activate(item) }
+			rule.preview = func() []string { return []string{"Sent back to the script " + p.name} }
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// Returns the running program, starts it if needed. p.lock must be held.
+func (p *ScriptProvider) start() (*script_process, error) {
+	if p.process != nil {
+		select {
+		case <-p.process.done:
+			p.process = nil // it has crashed, start it again
+		default:
+			return p.process, nil
+		}
+	}
+
+	cmd := exec.Command(p.exe, p.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	process := &script_process{
+		cmd:     cmd,
+		stdin:   stdin,
+		done:    make(chan struct{}),
+		waiting: map[int]chan []ScriptItem{},
+	}
+
+	// stderr goes to the log
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("Script %v: %v\n", p.name, scanner.Text())
+		}
+	}()
+
+	// the answers are given to the queries waiting for them
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(nil, 16*1024*1024)
+		for scanner.Scan() {
+			var response script_response
+			if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+				log.Printf("Script %v: invalid answer: %v\n", p.name, err)
+				continue
+			}
+			process.answer(response)
+		}
+
+		// the pipes must be fully read before Wait closes them
+		readers.Wait()
+		cmd.Wait()
+		close(process.done)
+	}()
+
+	p.process = process
+
+	return process, nil
+}
+
+// Kills the program, it will be restarted for the next query
+func (p *ScriptProvider) stop(process *script_process) {
+	p.lock.Lock()
+	if p.process == process {
+		p.process = nil
+	}
+	p.lock.Unlock()
+
+	process.cmd.Process.Kill()
+}
+
+// Stops the program, if it is running. Its stdin is closed so that it can
+// handle the messages already sent, it is killed if it does not exit in time.
+func (p *ScriptProvider) Close() {
+	p.lock.Lock()
+	process := p.process
+	p.process = nil
+	p.lock.Unlock()
+
+	if process == nil {
+		return
+	}
+
+	process.lock.Lock()
+	process.stdin.Close()
+	process.lock.Unlock()
+
+	select {
+	case <-process.done:
+	case <-time.After(SCRIPT_CLOSE_TIMEOUT * time.Millisecond):
+		log.Printf("Script %v: still running after %vms, it is killed\n", p.name, SCRIPT_CLOSE_TIMEOUT)
+		process.cmd.Process.Kill()
+	}
+}
+
+// Registers a query, returns the channel of its answer
+func (process *script_process) expect(id int) chan []ScriptItem {
+	process.lock.Lock()
+	defer process.lock.Unlock()
+
+	answer := make(chan []ScriptItem, 1)
+	process.waiting[id] = answer
+
+	return answer
+}
+
+// Forgets a query, its answer will be ignored
+func (process *script_process) forget(id int) {
+	process.lock.Lock()
+	defer process.lock.Unlock()
+
+	delete(process.waiting, id)
+}
+
+// Gives an answer to its query, if it is still waiting
+func (process *script_process) answer(response script_response) {
+	process.lock.Lock()
+	defer process.lock.Unlock()
+
+	if answer, ok := process.waiting[response.Id]; ok {
+		answer <- response.Results
+		delete(process.waiting, response.Id)
+	}
+}
+
+// Writes a message as a JSON line
+func (process *script_process) send(request script_request) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	process.lock.Lock()
+	defer process.lock.Unlock()
+
+	_, err = process.stdin.Write(append(data, '\n'))
+
+	return err
+}
@@ -0,0 +1,198 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Not a test: the fake script used by the tests, run by the test program itself
+func TestScriptHelper(t *testing.T) {
+	if os.Getenv("LAUNCHER_TEST_SCRIPT") != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			Id       int
+			Query    string
+			Activate *ScriptItem
+		}
+		json.Unmarshal(scanner.Bytes(), &request)
+
+		switch {
+		case request.Activate != nil:
+			if strings.HasPrefix(request.Activate.Title, "slow") {
+				time.Sleep(200 * time.Millisecond)
+			}
+			fmt.Fprintf(os.Stderr, "activated %v\n", request.Activate.Title)
+			continue
+		case request.Query == "crash":
+			os.Exit(1)
+		case request.Query == "slow":
+			time.Sleep(10 * time.Second)
+		}
+
+		fmt.Fprintf(os.Stdout, `{"id": %d, "results": [`+
+			`{"title": "%v", "subtitle": "for the script"},`+
+			`{"title": "%v best", "subtitle": "program", "exe": "dummy.exe", "args": ["a"], "score": 2}]}`+"\n",
+			request.Id, request.Query, request.Query)
+	}
+	os.Exit(0)
+}
+
+// Log output that can be written by several goroutines
+type test_log struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (l *test_log) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.buffer.Write(p)
+}
+
+func (l *test_log) Contains(s string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return strings.Contains(l.buffer.String(), s)
+}
+
+// Returns a provider running the fake script, and the log it writes to
+func new_test_script(t *testing.T) (*ScriptProvider, *test_log) {
+	t.Setenv("LAUNCHER_TEST_SCRIPT", "1")
+
+	output := &test_log{}
+	log.SetOutput(output)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	provider := NewScriptProvider(ScriptConfig{
+		Name:    "Script",
+		Exe:     os.Args[0],
+		Args:    []string{"-test.run=^TestScriptHelper$"},
+		Timeout: 500,
+	})
+	t.Cleanup(provider.Close)
+
+	return provider, output
+}
+
+// Runs a query with the provider, returns its results
+func search_script(t *testing.T, provider *ScriptProvider, text string) []*Rule {
+	t.Helper()
+
+	pipeline := NewPipeline([]Provider{provider})
+	defer pipeline.Stop()
+
+	pipeline.Start(ParseQuery(text, ""))
+	return wait_pipeline(t, pipeline)
+}
+
+func TestScriptProvider(t *testing.T) {
+	provider, output := new_test_script(t)
+
+	results := search_script(t, provider, "abc")
+	if got := strings.Join(RulesToAray(results), ","); got != "abc best,abc" {
+		t.Fatalf("results = %v, want them sorted by score", got)
+	}
+
+	// a result with a program runs it
+	best := results[0]
+	if exe, args, _ := best.Command(); exe != "dummy.exe" || len(args) != 1 || best.Section() != "Script" {
+		t.Errorf("rule = %v %v in %v", exe, args, best.Section())
+	}
+
+	// the other ones are sent back to the script
+	results[1].Execute()
+	wait_log(t, output, "Script Script: activated abc")
+	if results[1].UseCount != 1 {
+		t.Errorf("UseCount = %d after activation, want 1", results[1].UseCount)
+	}
+
+	// several queries are answered by the same program
+	process := provider.process
+	if results := search_script(t, provider, "def"); len(results) != 2 || provider.process != process {
+		t.Errorf("second query: %d results, restarted: %v", len(results), provider.process != process)
+	}
+}
+
+func TestScriptProviderClose(t *testing.T) {
+	provider, output := new_test_script(t)
+
+	// the launcher exits right after an activation
+	results := search_script(t, provider, "slow activation")
+	results[1].Execute()
+	provider.Close()
+
+	if !output.Contains("Script Script: activated slow activation") {
+		t.Errorf("the activation was not handled before Close returned")
+	}
+	if provider.process != nil {
+		t.Errorf("the program is still registered after Close")
+	}
+}
+
+func TestScriptProviderRestart(t *testing.T) {
+	provider, output := new_test_script(t)
+
+	tests := []struct {
+		query string
+		log   string
+	}{
+		{"crash", "the program has exited"},
+		{"slow", "no answer after 500ms"},
+	}
+
+	for _, test := range tests {
+		if results := search_script(t, provider, test.query); len(results) != 0 {
+			t.Errorf("%v: got %d results, want none", test.query, len(results))
+		}
+		wait_log(t, output, test.log)
+
+		// the program is started again for the next query
+		if results := search_script(t, provider, "after"); len(results) != 2 {
+			t.Errorf("after %v: got %d results, want 2", test.query, len(results))
+		}
+	}
+}
+
+func TestScriptConfigCheck(t *testing.T) {
+	tests := []struct {
+		config ScriptConfig
+		valid  bool
+	}{
+		{ScriptConfig{Name: "Calc", Exe: "calc.exe"}, true},
+		{ScriptConfig{Name: "Calc", Exe: "calc.exe", Timeout: 200}, true},
+		{ScriptConfig{Exe: "calc.exe"}, false},
+		{ScriptConfig{Name: "Calc"}, false},
+		{ScriptConfig{Name: "Calc", Exe: "calc.exe", Timeout: -1}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.config.Check(); (err == nil) != test.valid {
+			t.Errorf("%+v: Check() = %v, want valid %v", test.config, err, test.valid)
+		}
+	}
+}
+
+// Waits for a message in the log
+func wait_log(t *testing.T, output *test_log, message string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !output.Contains(message) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q not found in the log", message)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
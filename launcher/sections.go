@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"slices"
+)
+
+// Section of the rules of the config file
+const SECTION_RULES = "Rules"
+
+// Ways to order the results
+const (
+	GROUPING_SCORE   = "score"   // all the results sorted together (see SortRules)
+	GROUPING_SECTION = "section" // the results of each section together, under a header
+)
+
+// ListRow is a row of the result list: a rule, or the header of a section
+type ListRow struct {
+	Rule   *Rule  // nil for a header
+	Header string // name of the section, for a header
+}
+
+// Returns the section of the rule in the result list
+func (r *Rule) Section() string {
+	if r.section == "" {
+		return SECTION_RULES
+	}
+
+	return r.section
+}
+
+// Returns the rows of the result list, from rules already sorted.
+// Each section keeps at most its limit of rules (no limit if not set).
+// When grouping by section, the sections are in the given order (the others
+// after them, in order of appearance) and have a header if there are several.
+func GroupRules(rules []*Rule, grouping string, order []string, limits map[string]int) []ListRow {
+	rows := make([]ListRow, 0, len(rules))
+
+	// apply the limits, and list the sections in order of appearance
+	counts := map[string]int{}
+	sections := map[string][]*Rule{}
+	var names []string
+
+	for _, rule := range rules {
+		section := rule.Section()
+
+		if limit, ok := limits[section]; ok && limit > 0 && counts[section] >= limit {
+			continue
+		}
+		counts[section]++
+
+		if grouping != GROUPING_SECTION {
+			rows = append(rows, ListRow{Rule: rule})
+			continue
+		}
+
+		if _, ok := sections[section]; !ok {
+			names = append(names, section)
+		}
+		sections[section] = append(sections[section], rule)
+	}
+
+	if grouping != GROUPING_SECTION {
+		return rows
+	}
+
+	// the sections of the order first (stable sort keeps the others in order)
+	rank := func(name string) int {
+		if i := slices.Index(order, name); i != -1 {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(names, func(a, b string) int { return rank(a) - rank(b) })
+
+	for _, name := range names {
+		if len(names) > 1 {
+			rows = append(rows, ListRow{Header: name})
+		}
+		for _, rule := range sections[name] {
+			rows = append(rows, ListRow{Rule: rule})
+		}
+	}
+
+	return rows
+}
+
+// RowStrings gives the display strings of the rows of the result list. They
+// are computed when a row is displayed for the first time, and kept until the
+// rows change: a long list only costs the rows that are seen.
+type RowStrings struct {
+	rows        []ListRow
+	query       Query
+	search_desc bool
+	cache       map[int][]string // by row, for the rules
+}
+
+func NewRowStrings(rows []ListRow, query Query, search_desc bool) *RowStrings {
+	return &RowStrings{
+		rows:        rows,
+		query:       query,
+		search_desc: search_desc,
+		cache:       map[int][]string{},
+	}
+}
+
+// Returns the display strings of the rows first..last, nil for a header.
+// require is given the texts computed for the first time, and the headers
+// (eg: to load their characters in the font).
+func (s *RowStrings) Get(first int, last int, require func(texts ...string)) [][]string {
+	var result [][]string
+
+	for i := first; i <= last; i++ {
+		row := s.rows[i]
+		if row.Rule == nil {
+			require(row.Header)
+			result = append(result, nil)
+			continue
+		}
+
+		texts, ok := s.cache[i]
+		if !ok {
+			texts = row.Rule.DisplayStrings(s.query, s.search_desc)
+			s.cache[i] = texts
+			require(texts...)
+		}
+		result = append(result, texts)
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestGroupRules(t *testing.T) {
+	rule := func(match string, section string) *Rule {
+		return &Rule{Match: match, section: section}
+	}
+
+	// already sorted by score
+	rules := []*Rule{
+		rule("a", "Files"),
+		rule("b", ""),
+		rule("c", "Files"),
+		rule("d", "Applications"),
+		rule("e", ""),
+		rule("f", "Files"),
+	}
+
+	// a rule is shown by its match, a header by [name]
+	rows_text := func(rows []ListRow) []string {
+		var result []string
+		for _, row := range rows {
+			if row.Rule == nil {
+				result = append(result, "["+row.Header+"]")
+			} else {
+				result = append(result, row.Rule.Match)
+			}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		rules    []*Rule
+		grouping string
+		order    []string
+		limits   map[string]int
+		want     []string
+	}{
+		{"score", rules, GROUPING_SCORE, nil, nil, []string{"a", "b", "c", "d", "e", "f"}},
+		{"score limits", rules, GROUPING_SCORE, nil, map[string]int{"Files": 2, "Rules": 0}, []string{"a", "b", "c", "d", "e"}},
+		{"section", rules, GROUPING_SECTION, nil, nil, []string{"[Files]", "a", "c", "f", "[Rules]", "b", "e", "[Applications]", "d"}},
+		{"section order", rules, GROUPING_SECTION, []string{"Rules", "Applications", "Calculator"}, nil, []string{"[Rules]", "b", "e", "[Applications]", "d", "[Files]", "a", "c", "f"}},
+		{"section limits", rules, GROUPING_SECTION, []string{"Applications"}, map[string]int{"Files": 1, "Rules": 1}, []string{"[Applications]", "d", "[Files]", "a", "[Rules]", "b"}},
+		{"single section", rules[1:2], GROUPING_SECTION, nil, nil, []string{"b"}},
+		{"empty", nil, GROUPING_SECTION, nil, nil, nil},
+	}
+
+	for _, test := range tests {
+		got := rows_text(GroupRules(test.rules, test.grouping, test.order, test.limits))
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRowStrings(t *testing.T) {
+	rows := []ListRow{
+		{Header: "Files"},
+		{Rule: &Rule{Match: "notes.txt", Description: "Notes"}},
+		{Rule: &Rule{Match: "todo.md", Description: "Todo"}},
+	}
+	query := ParseQuery("no", "")
+
+	var required []string
+	require := func(texts ...string) { required = append(required, texts...) }
+
+	row_strings := NewRowStrings(rows, query, false)
+
+	// only the displayed rows are computed
+	got := row_strings.Get(0, 1, require)
+	want := [][]string{nil, {"no", "tes.txt - Notes"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(0, 1) = %q, want %q", got, want)
+	}
+	if _, ok := row_strings.cache[2]; ok {
+		t.Error("the row that is not displayed has been computed")
+	}
+
+	// then they are kept
+	required = nil
+	row_strings.Get(1, 2, require)
+	if want := []string{"", "todo.md - Todo"}; !slices.Equal(required, want) {
+		t.Errorf("texts required the second time = %q, want %q", required, want)
+	}
+
+	if got := row_strings.Get(0, -1, require); len(got) != 0 {
+		t.Errorf("Get of an empty list = %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package launcher
+
+import (
+	"strings"
+	"time"
+)
+
+// Returns true if the text typed after the Match of the rule (or one of its
+// aliases) is given to it as {args}. It is the case of the snippets using it.
+func (r *Rule) takes_args() bool {
+	return strings.Contains(r.Snippet, "{args}")
+}
+
+// Returns the text typed after the Match or an alias of the rule, if any
+func (r *Rule) keyword_args(text string) (string, bool) {
+	for _, keyword := range append([]string{r.Match}, r.Aliases...) {
+		if args, ok := cut_keyword(text, keyword); ok && args != "" {
+			return args, true
+		}
+	}
+
+	return "", false
+}
+
+// Returns the text of the snippet with its placeholders replaced:
+//   - ${NAME}: environment variable
+//   - {date}, {time} or {date:LAYOUT}: current date, with a Go layout (eg: {date:02/01/2006 15:04})
+//   - {clipboard}: text of the clipboard
+//   - {args}: text typed after the keyword, {1} or {name}: groups captured by MatchRegex
+//
+// The unknown placeholders are kept as they are.
+func (r *Rule) ExpandSnippet(now time.Time, clipboard func() string) string {
+	in := expand_env(r.Snippet)
+
+	var result strings.Builder
+	for {
+		start := strings.Index(in, "{")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(in[start:], "}")
+		if end == -1 {
+			break
+		}
+		end += start
+
+		result.WriteString(in[:start])
+		name := in[start+1 : end]
+
+		if value, ok := r.captures[name]; ok {
+			result.WriteString(value)
+		} else if layout, ok := strings.CutPrefix(name, "date:"); ok {
+			result.WriteString(now.Format(layout))
+		} else {
+			switch name {
+			case "date":
+				result.WriteString(now.Format("2006-01-02"))
+			case "time":
+				result.WriteString(now.Format("15:04"))
+			case "clipboard":
+				result.WriteString(clipboard())
+			case "args":
+				// no text typed after the keyword
+			default:
+				result.WriteString(in[start : end+1])
+			}
+		}
+
+		in = in[end+1:]
+	}
+
+	result.WriteString(in)
+
+	return result.String()
+}
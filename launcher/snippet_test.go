@@ -0,0 +1,108 @@
+package launcher
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpandSnippet(t *testing.T) {
+	t.Setenv("LAUNCHER_TEST_NAME", "Ada")
+	now := time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC)
+	clipboard := func() string { return "copied text" }
+
+	tests := []struct {
+		snippet  string
+		captures map[string]string
+		want     string
+	}{
+		{"plain text", nil, "plain text"},
+		{"Hello ${LAUNCHER_TEST_NAME}", nil, "Hello Ada"},
+		{"{date} {time}", nil, "2025-03-14 15:09"},
+		{"{date:02/01/2006 15h04}", nil, "14/03/2025 15h09"},
+		{"> {clipboard}", nil, "> copied text"},
+		{"Dear {args},", map[string]string{"args": "Bob"}, "Dear Bob,"},
+		{"Dear {args},", nil, "Dear ,"},
+		{"Ticket {1} ({id})", map[string]string{"1": "42", "id": "42"}, "Ticket 42 (42)"},
+		{"{unknown} {", nil, "{unknown} {"},
+	}
+
+	for _, test := range tests {
+		rule := Rule{Match: "s", Description: "snippet", Snippet: test.snippet, captures: test.captures}
+		if got := rule.ExpandSnippet(now, clipboard); got != test.want {
+			t.Errorf("ExpandSnippet(%q) = %q, want %q", test.snippet, got, test.want)
+		}
+	}
+}
+
+func TestSnippetArgs(t *testing.T) {
+	signature := &Rule{Match: "sig", Description: "Signature for {args}", Aliases: []string{"signature"}, Snippet: "Regards,\n{args}"}
+	other := &Rule{Match: "signal", Description: "Open signal", Exe: "signal.exe"}
+	rules := []*Rule{signature, other}
+	for _, rule := range rules {
+		if err := rule.Check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+		args  string
+	}{
+		{"si", []string{"sig", "signal"}, ""},
+		{"sig", []string{"sig", "signal"}, ""},
+		{"sig John Smith", []string{"sig"}, "John Smith"},
+		{"signature Ada", []string{"sig"}, "Ada"},
+		{"signal", []string{"signal"}, ""},
+	}
+
+	for _, test := range tests {
+		query := ParseQuery(test.input, "")
+
+		// the same with and without the index
+		for _, got := range [][]*Rule{query.Filter(rules, false), NewSearchIndex(rules).Filter(query, false)} {
+			names := RulesToAray(got)
+			slices.Sort(names)
+			if !slices.Equal(names, test.want) {
+				t.Errorf("%q: got %v, want %v", test.input, names, test.want)
+			}
+		}
+
+		if args := signature.captures["args"]; args != test.args {
+			t.Errorf("%q: args = %q, want %q", test.input, args, test.args)
+		}
+	}
+
+	// the description shows the arguments
+	ParseQuery("sig Ada", "").Filter(rules, false)
+	if got := signature.DisplayStrings(ParseQuery("sig Ada", ""), false); got[len(got)-1] != " - Signature for Ada" {
+		t.Errorf("display strings = %q", got)
+	}
+}
+
+func TestSnippetExecute(t *testing.T) {
+	clipboard := &test_clipboard{text: "previous"}
+	system_clipboard = clipboard
+	t.Cleanup(func() { system_clipboard = raylib_clipboard{} })
+
+	rule := &Rule{Match: "quote", Description: "Quote the clipboard", Snippet: "> {clipboard}"}
+	if err := rule.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	rule.Execute()
+
+	if clipboard.text != "> previous" {
+		t.Errorf("clipboard = %q, want %q", clipboard.text, "> previous")
+	}
+	if rule.UseCount != 1 {
+		t.Errorf("UseCount = %d, want 1", rule.UseCount)
+	}
+
+	// a snippet does not run a program
+	rule.Exe = "notepad.exe"
+	if rule.Check() == nil {
+		t.Error("Check() = nil for a snippet with an Exe")
+	}
+}
@@ -0,0 +1,278 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const SSH_MAX_INCLUDES = 16 // depth of the Include directives, to avoid loops
+
+// Settings of the SSH hosts provider in the config file
+type SSHConfig struct {
+	Enabled    bool
+	ConfigFile string `toml:",omitempty"` // ~/.ssh/config if empty
+	KnownHosts string `toml:",omitempty"` // known_hosts file, ~/.ssh/known_hosts if empty, "none" to ignore it
+}
+
+// A host found in the SSH files
+type ssh_host struct {
+	name     string // what is given to ssh
+	hostname string // from HostName, "" if not set
+	user     string
+	port     string
+	known    bool // from known_hosts
+}
+
+// SSHProvider lists the hosts of the SSH config file (the Host entries without
+// wildcards) and of the known_hosts file (the hashed ones can not be read).
+// Each host opens the terminal running "ssh <host>".
+type SSHProvider struct {
+	config_file string
+	known_hosts string
+	terminal    TerminalConfig
+	usage       *UsageStore
+}
+
+func NewSSHProvider(config SSHConfig, terminal TerminalConfig, usage *UsageStore) *SSHProvider {
+	provider := &SSHProvider{
+		config_file: expand_home(config.ConfigFile),
+		known_hosts: expand_home(config.KnownHosts),
+		terminal:    terminal,
+		usage:       usage,
+	}
+
+	if config.ConfigFile == "" {
+		provider.config_file = expand_home("~/.ssh/config")
+	}
+	if config.KnownHosts == "" {
+		provider.known_hosts = expand_home("~/.ssh/known_hosts")
+	}
+	if config.KnownHosts == "none" {
+		provider.known_hosts = ""
+	}
+
+	return provider
+}
+
+func (p *SSHProvider) Name() string {
+	return "SSH"
+}
+
+// The files are read for each query, they are small
+func (p *SSHProvider) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	hosts := parse_ssh_config(p.config_file)
+	if p.known_hosts != "" {
+		for _, host := range parse_known_hosts(p.known_hosts) {
+			if !slices.ContainsFunc(hosts, func(h ssh_host) bool { return h.name == host.name || h.hostname == host.name }) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	var rules []*Rule
+	for _, host := range hosts {
+		rules = append(rules, p.rule(host))
+	}
+
+	SendResults(ctx, results, query.Filter(rules, false))
+}
+
+// Returns the rule opening a terminal connected to the host
+func (p *SSHProvider) rule(host ssh_host) *Rule {
+	command := []string{"ssh", host.name}
+	description := "ssh "
+	if host.user != "" {
+		description += host.user + "@"
+	}
+	if host.hostname != "" {
+		description += host.hostname
+	} else {
+		description += host.name
+	}
+	if host.port != "" {
+		description += ":" + host.port
+		if host.known {
+			command = []string{"ssh", "-p", host.port, host.name}
+		}
+	}
+	if host.known {
+		description += " (known host)"
+	}
+
+	rule := &Rule{
+		Match:       host.name,
+		Description: description,
+		Tags:        []string{"ssh"},
+		Icon:        "terminal",
+	}
+	rule.Exe, rule.Args = p.terminal.Command(command)
+	p.usage.Track(rule, "SSH:"+host.name)
+
+	return rule
+}
+
+// Returns the hosts of an SSH config file, and of the files it includes
+func parse_ssh_config(path string) []ssh_host {
+	var hosts []ssh_host
+	read_ssh_config(path, &hosts, nil, 0)
+
+	return hosts
+}
+
+// Reads a config file, current are the hosts of the Host entry where it is
+// included. Like ssh does, the settings at the start of an included file apply
+// to this entry, and the entry goes on after the Include line.
+func read_ssh_config(path string, hosts *[]ssh_host, current []int, depth int) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	// current are the hosts of the current Host entry, none in a Match entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, values := ssh_config_line(scanner.Text())
+
+		switch key {
+		case "host":
+			current = nil
+			for _, name := range values {
+				if strings.ContainsAny(name, "*?!") {
+					continue
+				}
+				current = append(current, len(*hosts))
+				*hosts = append(*hosts, ssh_host{name: name})
+			}
+
+		case "match":
+			current = nil
+
+		case "include":
+			if depth >= SSH_MAX_INCLUDES {
+				continue
+			}
+			for _, pattern := range values {
+				pattern = expand_home(pattern)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(expand_home("~/.ssh"), pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					read_ssh_config(match, hosts, current, depth+1)
+				}
+			}
+
+		case "hostname", "user", "port":
+			if len(values) == 0 {
+				continue
+			}
+			// the first value is used, like ssh does
+			for _, i := range current {
+				host := &(*hosts)[i]
+				if key == "hostname" && host.hostname == "" {
+					host.hostname = values[0]
+				} else if key == "user" && host.user == "" {
+					host.user = values[0]
+				} else if key == "port" && host.port == "" {
+					host.port = values[0]
+				}
+			}
+		}
+	}
+}
+
+// Returns the keyword (in lower case) and the values of a line of an SSH config
+// file: "Keyword value ..." or "Keyword=value", separated by spaces or tabs,
+// the values can be quoted
+func ssh_config_line(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+
+	end := strings.IndexFunc(line, func(c rune) bool { return c == '=' || unicode.IsSpace(c) })
+	if end == -1 {
+		end = len(line)
+	}
+	key, rest := line[:end], strings.TrimSpace(line[end:])
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, "="))
+
+	var values []string
+	for ; rest != ""; rest = strings.TrimSpace(rest) {
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else if end := strings.IndexFunc(rest, unicode.IsSpace); end != -1 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		values = append(values, value)
+	}
+
+	return strings.ToLower(key), values
+}
+
+// Returns the hosts of a known_hosts file. The hashed hosts, the patterns
+// and the lines with a marker (@cert-authority, @revoked) are ignored.
+func parse_known_hosts(path string) []ssh_host {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var hosts []ssh_host
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "@") || strings.HasPrefix(fields[0], "|") {
+			continue
+		}
+
+		for _, name := range strings.Split(fields[0], ",") {
+			if name == "" || strings.ContainsAny(name, "*?!|") {
+				continue
+			}
+
+			host := ssh_host{name: name, known: true}
+
+			// [host]:port for the ports other than 22
+			if strings.HasPrefix(name, "[") {
+				end := strings.Index(name, "]")
+				if end == -1 {
+					continue
+				}
+				host.name = name[1:end]
+				host.port, _ = strings.CutPrefix(name[end+1:], ":")
+			}
+
+			if !slices.ContainsFunc(hosts, func(h ssh_host) bool { return h.name == host.name }) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	return hosts
+}
+
+// Replaces the ~ at the start of a path by the home directory
+func expand_home(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,179 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Writes files in a fake home directory, returns its path
+func write_home(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	for name, content := range files {
+		path := filepath.Join(home, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return home
+}
+
+func TestParseSSHConfig(t *testing.T) {
+	home := write_home(t, map[string]string{
+		".ssh/config": `# comment
+Include conf.d/*.conf
+
+Host web web-alias
+    HostName web.example.com
+    User deploy
+    Port 2222
+
+Host *.internal !secret
+    User admin
+
+Host=db
+  HostName = "db.example.com"
+  User root
+  User ignored
+
+Match host nas
+  User nas
+`,
+		".ssh/conf.d/work.conf": `Host work
+  hostname work.example.com
+Include ` + "~/.ssh/config" + `
+`,
+	})
+
+	hosts := parse_ssh_config(filepath.Join(home, ".ssh", "config"))
+
+	want := []ssh_host{
+		{name: "work", hostname: "work.example.com"},
+		{name: "web", hostname: "web.example.com", user: "deploy", port: "2222"},
+		{name: "web-alias", hostname: "web.example.com", user: "deploy", port: "2222"},
+		{name: "db", hostname: "db.example.com", user: "root"},
+	}
+
+	// the loop of includes stops, the hosts are then found several times
+	var unique []ssh_host
+	for _, host := range hosts {
+		if !slices.Contains(unique, host) {
+			unique = append(unique, host)
+		}
+	}
+	if !slices.Equal(unique, want) {
+		t.Errorf("hosts = %+v\nwant %+v", unique, want)
+	}
+
+	// separated by tabs, and an Include in a Host entry
+	home = write_home(t, map[string]string{
+		".ssh/config": "Host\tmail\tmail-alias\n" +
+			"\tHostName\tmail.example.com\n" +
+			"\tUser=\t\"post master\"\n" +
+			"\tInclude mail.conf\n" +
+			"\tPort 25\n" +
+			"Host\tftp\n" +
+			"\tPort\t2121\n",
+		".ssh/mail.conf": "Port 2525\nHost relay\n",
+	})
+
+	hosts = parse_ssh_config(filepath.Join(home, ".ssh", "config"))
+
+	want = []ssh_host{
+		{name: "mail", hostname: "mail.example.com", user: "post master", port: "2525"},
+		{name: "mail-alias", hostname: "mail.example.com", user: "post master", port: "2525"},
+		{name: "relay"},
+		{name: "ftp", port: "2121"},
+	}
+	if !slices.Equal(hosts, want) {
+		t.Errorf("hosts = %+v\nwant %+v", hosts, want)
+	}
+}
+
+func TestParseKnownHosts(t *testing.T) {
+	home := write_home(t, map[string]string{
+		".ssh/known_hosts": `github.com,140.82.121.4 ssh-ed25519 AAAA
+|1|hashedsalt=|hashedhost= ssh-rsa AAAA
+[git.example.com]:2022 ssh-ed25519 AAAA
+@cert-authority *.example.com ssh-rsa AAAA
+*.wildcard.com ssh-rsa AAAA
+github.com ecdsa-sha2-nistp256 AAAA
+# comment
+`,
+	})
+
+	hosts := parse_known_hosts(filepath.Join(home, ".ssh", "known_hosts"))
+
+	want := []ssh_host{
+		{name: "github.com", known: true},
+		{name: "140.82.121.4", known: true},
+		{name: "git.example.com", port: "2022", known: true},
+	}
+	if !slices.Equal(hosts, want) {
+		t.Errorf("hosts = %+v\nwant %+v", hosts, want)
+	}
+}
+
+func TestSSHProvider(t *testing.T) {
+	write_home(t, map[string]string{
+		".ssh/config": `Host web
+  HostName web.example.com
+  User deploy
+`,
+		".ssh/known_hosts": `web.example.com ssh-ed25519 AAAA
+[git.example.com]:2022 ssh-ed25519 AAAA
+`,
+	})
+
+	usage := NewUsageStore(&map[string]RuleUsage{})
+	terminal := TerminalConfig{Exe: "xterm", Args: []string{"-e", "{args}"}}
+	provider := NewSSHProvider(SSHConfig{Enabled: true}, terminal, usage)
+
+	search := func(input string) []*Rule {
+		results := make(chan []*Rule, 1)
+		provider.Search(context.Background(), ParseQuery(input, ""), results)
+		return <-results
+	}
+
+	rules := search("")
+	if got := RulesToAray(rules); !slices.Equal(got, []string{"web", "git.example.com"}) {
+		t.Fatalf("hosts = %v", got)
+	}
+
+	tests := []struct {
+		rule        *Rule
+		description string
+		args        []string
+	}{
+		{rules[0], "ssh deploy@web.example.com", []string{"-e", "ssh", "web"}},
+		{rules[1], "ssh git.example.com:2022 (known host)", []string{"-e", "ssh", "-p", "2022", "git.example.com"}},
+	}
+	for _, test := range tests {
+		if test.rule.Description != test.description || test.rule.Exe != "xterm" || !slices.Equal(test.rule.Args, test.args) {
+			t.Errorf("%v: %q %v %v, want %q xterm %v", test.rule.Match, test.rule.Description, test.rule.Exe, test.rule.Args, test.description, test.args)
+		}
+	}
+
+	// the hosts are searched like rules, with the ssh tag
+	if got := RulesToAray(search("#ssh git")); !slices.Equal(got, []string{"git.example.com"}) {
+		t.Errorf("#ssh git: %v", got)
+	}
+
+	// the use is remembered for the next queries
+	rules[0].execute = func() {} // instead of starting the terminal
+	rules[0].Execute()
+	if again := search("web"); again[0].UseCount != 1 {
+		t.Errorf("UseCount = %d after use, want 1", again[0].UseCount)
+	}
+}
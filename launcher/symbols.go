@@ -0,0 +1,286 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+//go:generate go run symbols_gen.go
+
+// Names of the Unicode characters, one per line: "1F604 smiling face with open mouth and smiling eyes"
+//
+//go:embed symbols.txt.gz
+var symbols_data []byte
+
+const (
+	SYMBOL_MAX_RESULTS = 100 // the other matching symbols are not listed
+	SYMBOL_MAX_RECENT  = 50  // number of used symbols remembered
+)
+
+// Short names of common emoji, like in chat applications (:smile:)
+var emoji_shortcodes = map[string]rune{
+	"smile": 0x1F604, "smiley": 0x1F603, "grin": 0x1F601, "grinning": 0x1F600, "laughing": 0x1F606,
+	"joy": 0x1F602, "rofl": 0x1F923, "wink": 0x1F609, "blush": 0x1F60A, "heart_eyes": 0x1F60D,
+	"kissing_heart": 0x1F618, "sunglasses": 0x1F60E, "thinking": 0x1F914, "neutral_face": 0x1F610,
+	"unamused": 0x1F612, "sweat_smile": 0x1F605, "cry": 0x1F622, "sob": 0x1F62D, "angry": 0x1F620,
+	"rage": 0x1F621, "scream": 0x1F631, "sleeping": 0x1F634, "see_no_evil": 0x1F648,
+	"heart": 0x2764, "broken_heart": 0x1F494, "thumbsup": 0x1F44D, "+1": 0x1F44D, "thumbsdown": 0x1F44E,
+	"-1": 0x1F44E, "ok_hand": 0x1F44C, "clap": 0x1F44F, "wave": 0x1F44B, "pray": 0x1F64F,
+	"muscle": 0x1F4AA, "eyes": 0x1F440, "fire": 0x1F525, "star": 0x2B50, "sparkles": 0x2728,
+	"tada": 0x1F389, "rocket": 0x1F680, "100": 0x1F4AF, "warning": 0x26A0, "white_check_mark": 0x2705,
+	"x": 0x274C, "bulb": 0x1F4A1, "bug": 0x1F41B, "coffee": 0x2615, "beer": 0x1F37A, "pizza": 0x1F355,
+	"cake": 0x1F370, "sunny": 0x2600, "cloud": 0x2601, "zap": 0x26A1, "snowflake": 0x2744,
+	"poop": 0x1F4A9, "ghost": 0x1F47B, "skull": 0x1F480, "cat": 0x1F431, "dog": 0x1F436,
+}
+
+// A character of the picker
+type symbol struct {
+	char       rune
+	name       string // lower case Unicode name
+	shortcodes string // emoji short names, separated by spaces
+	emoji      bool
+}
+
+// Settings of the symbol picker in the config file
+type SymbolsConfig struct {
+	Enabled bool
+	Recent  []SymbolUse `toml:",omitempty"` // updated by the launcher
+}
+
+// Usage of a symbol, the recent ones are listed first
+type SymbolUse struct {
+	Symbol   string
+	LastUse  time.Time
+	UseCount int `toml:",omitempty"`
+}
+
+// SymbolPicker lists the characters for the queries starting with ':', searched
+// by name (eg: ":e acute" or ":smile"), or with 'u+', by code point (eg: "u+e9").
+// Selecting one copies it to the clipboard.
+type SymbolPicker struct {
+	lock   sync.Mutex   // the uses are read in the background
+	recent *[]SymbolUse // in the config, the most recent first
+}
+
+var (
+	symbols      []symbol
+	symbols_once sync.Once
+)
+
+func NewSymbolPicker(config *SymbolsConfig) *SymbolPicker {
+	return &SymbolPicker{recent: &config.Recent}
+}
+
+func (p *SymbolPicker) Name() string {
+	return "Symbols"
+}
+
+func (p *SymbolPicker) Search(ctx context.Context, query Query, results chan<- []*Rule) {
+	var found []symbol
+
+	if hex, ok := cut_prefix_fold(query.Text, "u+"); ok {
+		found = search_code_point(hex)
+	} else if name, ok := strings.CutPrefix(query.Text, ":"); ok {
+		// the name is not a query: "-1" is a shortcode, not an excluded term
+		found = search_symbols(strings.Fields(strings.Trim(name, ":")))
+	} else {
+		return
+	}
+
+	rules := make([]*Rule, 0, len(found))
+	for _, s := range found {
+		rules = append(rules, p.rule(s))
+	}
+
+	// the most recent first, sorted again with the other results
+	slices.SortStableFunc(rules, func(a, b *Rule) int { return b.LastUse.Compare(a.LastUse) })
+
+	SendResults(ctx, results, rules)
+}
+
+// Returns the rule that copies the symbol
+func (p *SymbolPicker) rule(s symbol) *Rule {
+	char := string(s.char)
+
+	description := fmt.Sprintf("U+%04X %v", s.char, s.name)
+	if s.shortcodes != "" {
+		description += " :" + strings.ReplaceAll(s.shortcodes, " ", ": :") + ":"
+	}
+
+	rule := &Rule{
+		Match:       char,
+		Description: description,
+		execute:     func() { p.use(char, time.Now()) },
+		preview:     func() []string { return []string{"Copied to the clipboard: " + char} },
+	}
+
+	p.lock.Lock()
+	for _, use := range *p.recent {
+		if use.Symbol == char {
+			rule.LastUse = use.LastUse
+			rule.UseCount = use.UseCount
+		}
+	}
+	p.lock.Unlock()
+
+	return rule
+}
+
+// Copies the symbol and remembers its use
+func (p *SymbolPicker) use(char string, now time.Time) {
+	system_clipboard.Set(char)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	use := SymbolUse{Symbol: char}
+	if i := slices.IndexFunc(*p.recent, func(u SymbolUse) bool { return u.Symbol == char }); i != -1 {
+		use = (*p.recent)[i]
+		*p.recent = slices.Delete(*p.recent, i, i+1)
+	}
+	use.LastUse = now
+	use.UseCount++
+
+	*p.recent = append([]SymbolUse{use}, *p.recent...)
+	*p.recent = (*p.recent)[:min(len(*p.recent), SYMBOL_MAX_RECENT)]
+}
+
+// Returns the symbols whose name or shortcodes have all the terms at the start
+// of a word: the exact shortcodes first, then the emoji, then the other ones
+func search_symbols(terms []string) []symbol {
+	load_symbols()
+
+	var found []symbol
+	var scores []int
+	for i := range terms {
+		terms[i] = fold_string(terms[i])
+	}
+
+	for _, s := range symbols {
+		words := s.shortcodes + " " + s.name
+		if !all_terms(words, terms) {
+			continue
+		}
+
+		score := 0
+		if s.emoji {
+			score = 1
+		}
+		if len(terms) == 1 && slices.Contains(strings.Fields(s.shortcodes), terms[0]) {
+			score = 2
+		}
+		found = append(found, s)
+		scores = append(scores, score)
+	}
+
+	order := make([]int, len(found))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return scores[b] - scores[a] })
+
+	var result []symbol
+	for _, i := range order[:min(len(order), SYMBOL_MAX_RESULTS)] {
+		result = append(result, found[i])
+	}
+
+	return result
+}
+
+func all_terms(words string, terms []string) bool {
+	for _, term := range terms {
+		if index_term(words, term, true) == -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the character of the code point, and the named ones starting with it
+func search_code_point(hex string) []symbol {
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || code > unicode.MaxRune {
+		return nil
+	}
+
+	load_symbols()
+
+	var result []symbol
+	prefix := strings.ToUpper(hex)
+	for _, s := range symbols {
+		if s.char == rune(code) {
+			result = append([]symbol{s}, result...)
+		} else if len(result) < SYMBOL_MAX_RESULTS && strings.HasPrefix(fmt.Sprintf("%X", s.char), prefix) {
+			result = append(result, s)
+		}
+	}
+
+	// the characters without name can also be copied (eg: CJK ideographs)
+	if (len(result) == 0 || result[0].char != rune(code)) && unicode.IsGraphic(rune(code)) {
+		result = append([]symbol{{char: rune(code), name: "(no name)"}}, result...)
+	}
+
+	return result[:min(len(result), SYMBOL_MAX_RESULTS)]
+}
+
+// Reads the embedded names, the first time they are needed
+func load_symbols() {
+	symbols_once.Do(func() {
+		reader, err := gzip.NewReader(bytes.NewReader(symbols_data))
+		if err != nil {
+			log.Println("Could not read the symbol names:", err)
+			return
+		}
+
+		shortcodes := map[rune][]string{}
+		for code, char := range emoji_shortcodes {
+			shortcodes[char] = append(shortcodes[char], code)
+		}
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			hex, name, _ := strings.Cut(scanner.Text(), " ")
+			code, err := strconv.ParseUint(hex, 16, 32)
+			if err != nil {
+				continue
+			}
+
+			char := rune(code)
+			codes := shortcodes[char]
+			slices.Sort(codes)
+
+			symbols = append(symbols, symbol{
+				char:       char,
+				name:       name,
+				shortcodes: strings.Join(codes, " "),
+				emoji:      is_emoji(char) || len(codes) != 0,
+			})
+		}
+	})
+}
+
+// Returns true if the character is in one of the main emoji blocks
+func is_emoji(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF)
+}
+
+// Same as strings.CutPrefix, ignoring case
+func cut_prefix_fold(text string, prefix string) (string, bool) {
+	if len(text) < len(prefix) || !strings.EqualFold(text[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return text[len(prefix):], true
+}
@@ -0,0 +1,52 @@
+//go:build ignore
+
+// Generates symbols.txt.gz, the names of the Unicode characters used by the
+// symbol picker. Run with: go generate ./launcher
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/runenames"
+)
+
+// Characters whose name is only their code point
+var skipped = []*unicode.RangeTable{unicode.Han, unicode.Hangul, unicode.Tangut, unicode.Nushu, unicode.Khitan_Small_Script, unicode.Co, unicode.Cs, unicode.Cc}
+
+func main() {
+	file, err := os.Create("symbols.txt.gz")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	writer, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// one character per line: its code point in hexadecimal and its name in lower case
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if !unicode.IsGraphic(r) || unicode.In(r, skipped...) {
+			continue
+		}
+
+		name := runenames.Name(r)
+		if name == "" || strings.HasPrefix(name, "<") {
+			continue
+		}
+
+		fmt.Fprintf(writer, "%X %v\n", r, strings.ToLower(name))
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
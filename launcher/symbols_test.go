@@ -0,0 +1,85 @@
+package launcher
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func search_picker(picker *SymbolPicker, input string) []*Rule {
+	results := make(chan []*Rule, 1)
+	picker.Search(context.Background(), ParseQuery(input, ""), results)
+	close(results)
+
+	return <-results
+}
+
+func TestSymbolPickerSearch(t *testing.T) {
+	picker := NewSymbolPicker(&SymbolsConfig{})
+
+	tests := []struct {
+		input string
+		first string // first result
+		count int    // number of results, -1 if not checked
+	}{
+		{":smile", "😄", -1}, // shortcode first
+		{":-1", "👎", -1},    // not an excluded term
+		{":-1:", "👎", -1},
+		{`:"thumbs up"`, "", 0},     // not a phrase
+		{":e acute", "É", -1},       // in the order of the code points
+		{":SMALL E ACUTE", "é", -1}, // case is ignored
+		{":grinning face", "😀", -1}, // emoji first
+		{":zzzunknown", "", 0},
+		{"u+00e9", "é", -1},
+		{"U+E9", "é", -1},  // then the code points starting with E9
+		{"u+4e2d", "中", 1}, // no name, but it can be copied
+		{"u+zz", "", 0},
+		{"u+110000", "", 0},
+		{"smile", "", 0}, // not a symbol query
+	}
+
+	for _, test := range tests {
+		rules := search_picker(picker, test.input)
+
+		if test.count != -1 && len(rules) != test.count {
+			t.Errorf("%q: %d results, want %d", test.input, len(rules), test.count)
+		}
+		if test.first != "" && (len(rules) == 0 || rules[0].Match != test.first) {
+			t.Errorf("%q: results %v, want %q first", test.input, RulesToAray(rules[:min(len(rules), 5)]), test.first)
+		}
+	}
+
+	if rules := search_picker(picker, ":"); len(rules) != SYMBOL_MAX_RESULTS {
+		t.Errorf("%d results for all the symbols, want %d", len(rules), SYMBOL_MAX_RESULTS)
+	}
+}
+
+func TestSymbolPickerRecent(t *testing.T) {
+	clipboard := &test_clipboard{}
+	system_clipboard = clipboard
+	t.Cleanup(func() { system_clipboard = raylib_clipboard{} })
+
+	config := &SymbolsConfig{}
+	picker := NewSymbolPicker(config)
+
+	// the used symbols are listed first, the most recent first
+	heart := search_picker(picker, ":heart")
+	for _, rule := range heart[3:5] {
+		rule.Execute()
+		time.Sleep(time.Millisecond)
+	}
+
+	if clipboard.text != heart[4].Match {
+		t.Errorf("clipboard = %q, want %q", clipboard.text, heart[4].Match)
+	}
+
+	want := []string{heart[4].Match, heart[3].Match}
+	if got := RulesToAray(search_picker(picker, ":heart")[:2]); !slices.Equal(got, want) {
+		t.Errorf("first results = %v, want %v", got, want)
+	}
+
+	if len(config.Recent) != 2 || config.Recent[0].Symbol != heart[4].Match || config.Recent[0].UseCount != 1 {
+		t.Errorf("recent symbols = %+v", config.Recent)
+	}
+}
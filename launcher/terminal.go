@@ -0,0 +1,92 @@
+package launcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Settings of the terminal used to run command line programs (eg: ssh)
+type TerminalConfig struct {
+	Exe  string
+	Args []string // the argument {args} is replaced by the program and its arguments, {command} by the same in a single string (quoted for a POSIX shell)
+}
+
+// Sets the default terminal of the system if there is none, and checks it
+func (t *TerminalConfig) Check() error {
+	if t.Exe == "" && len(t.Args) == 0 {
+		*t = default_terminal(runtime.GOOS)
+	}
+
+	if t.Exe == "" {
+		return errors.New("invalid terminal, Exe field is empty")
+	}
+
+	return nil
+}
+
+// Returns the default terminal of a system
+func default_terminal(goos string) TerminalConfig {
+	if goos == "windows" {
+		return TerminalConfig{Exe: "wt.exe", Args: []string{"{args}"}}
+	}
+
+	return TerminalConfig{Exe: "x-terminal-emulator", Args: []string{"-e", "{args}"}}
+}
+
+// Returns the program and arguments opening an interactive shell in the
+// directory, the working directory of the program must also be set to it.
+// The shell is $SHELL (sh if not set), or %ComSpec% on Windows (cmd.exe if
+// not set). Windows Terminal ignores the working directory: it is given the
+// directory with -d, and it opens its default profile.
+func (t TerminalConfig) Shell(dir string) (string, []string) {
+	if name := strings.ToLower(filepath.Base(t.Exe)); name == "wt" || name == "wt.exe" {
+		exe, args := t.Command(nil)
+		return exe, append([]string{"-d", dir}, args...)
+	}
+
+	shell, fallback := os.Getenv("SHELL"), "sh"
+	if runtime.GOOS == "windows" {
+		shell, fallback = os.Getenv("ComSpec"), "cmd.exe"
+	}
+	if shell == "" {
+		shell = fallback
+	}
+
+	return t.Command([]string{shell})
+}
+
+// Returns the program and arguments running the command in the terminal
+func (t TerminalConfig) Command(command []string) (string, []string) {
+	var args []string
+
+	quoted := []string{}
+	for _, arg := range command {
+		quoted = append(quoted, shell_quote(arg))
+	}
+
+	for _, arg := range t.Args {
+		if arg == "{args}" {
+			args = append(args, command...)
+		} else {
+			args = append(args, strings.ReplaceAll(arg, "{command}", strings.Join(quoted, " ")))
+		}
+	}
+
+	return t.Exe, args
+}
+
+// Returns the argument quoted for a POSIX shell: in single quotes, where
+// nothing is expanded ($, `, \), unless it only has safe characters
+func shell_quote(arg string) string {
+	unsafe := strings.IndexFunc(arg, func(c rune) bool {
+		return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./:=@%+,", c))
+	})
+	if arg != "" && unsafe == -1 {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,99 @@
+package launcher
+
+import (
+	"os/exec"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestTerminalCommand(t *testing.T) {
+	command := []string{"ssh", "-p", "2022", "host"}
+	shell := []string{"bash", "-c", "echo 'hello world'"}
+
+	tests := []struct {
+		args     []string
+		want_exe string
+		want     []string
+	}{
+		{[]string{"{args}"}, "term", []string{"ssh", "-p", "2022", "host"}},
+		{[]string{"-e", "{args}"}, "term", []string{"-e", "ssh", "-p", "2022", "host"}},
+		{[]string{"-e", "bash", "-c", "{command}; exec bash"}, "term", []string{"-e", "bash", "-c", "ssh -p 2022 host; exec bash"}},
+		{[]string{"-e", "bash", "-c", "{command}"}, "term", []string{"-e", "bash", "-c", "ssh -p 2022 host"}},
+	}
+
+	for _, test := range tests {
+		exe, args := TerminalConfig{Exe: "term", Args: test.args}.Command(command)
+		if exe != test.want_exe || !slices.Equal(args, test.want) {
+			t.Errorf("%v: %v %q, want %v %q", test.args, exe, args, test.want_exe, test.want)
+		}
+	}
+
+	// {command} is run by a shell, nothing must be expanded before bash
+	shells := []struct {
+		command []string
+		want    string
+	}{
+		{shell, `bash -c 'echo '\''hello world'\'''`},
+		{[]string{"bash", "-c", `for f in *; do echo "$f"; done`}, `bash -c 'for f in *; do echo "$f"; done'`},
+		{[]string{"bash", "-c", `printf '%s\n' $HOME`}, `bash -c 'printf '\''%s\n'\'' $HOME'`},
+		{[]string{"ssh", ""}, `ssh ''`},
+	}
+	for _, test := range shells {
+		exe, args := TerminalConfig{Exe: "term", Args: []string{"-e", "{command}"}}.Command(test.command)
+		if want := []string{"-e", test.want}; exe != "term" || !slices.Equal(args, want) {
+			t.Errorf("shell command %q: %v %q, want %q", test.command, exe, args, want)
+		}
+	}
+
+	// there is a default terminal
+	var terminal TerminalConfig
+	if err := terminal.Check(); err != nil || terminal.Exe == "" {
+		t.Errorf("default terminal: %+v, %v", terminal, err)
+	}
+	if err := (&TerminalConfig{Args: []string{"{args}"}}).Check(); err == nil {
+		t.Error("Check() = nil for a terminal without Exe")
+	}
+}
+
+func TestTerminalShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the shell is %ComSpec% on Windows")
+	}
+
+	tests := []struct {
+		terminal TerminalConfig
+		shell    string
+		want_exe string
+		want     []string
+	}{
+		{default_terminal("linux"), "/bin/zsh", "x-terminal-emulator", []string{"-e", "/bin/zsh"}},
+		{default_terminal("linux"), "", "x-terminal-emulator", []string{"-e", "sh"}},
+		{default_terminal("windows"), "/bin/zsh", "wt.exe", []string{"-d", "/home/ada/code"}},
+		{TerminalConfig{Exe: "wt", Args: []string{"-p", "Ubuntu", "{args}"}}, "", "wt", []string{"-d", "/home/ada/code", "-p", "Ubuntu"}},
+		{TerminalConfig{Exe: "kitty", Args: []string{"sh", "-c", "{command}"}}, "/bin/bash", "kitty", []string{"sh", "-c", "/bin/bash"}},
+	}
+
+	for _, test := range tests {
+		t.Setenv("SHELL", test.shell)
+
+		exe, args := test.terminal.Shell("/home/ada/code")
+		if exe != test.want_exe || !slices.Equal(args, test.want) {
+			t.Errorf("%+v with SHELL=%q: %v %q, want %v %q", test.terminal, test.shell, exe, args, test.want_exe, test.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no POSIX shell")
+	}
+
+	// the shell gives back the same argument
+	for _, arg := range []string{"plain", "", "two words", `it's "$HOME" \n`, "`date` $(id) *; exit 1", "tab\tand\nnewline"} {
+		output, err := exec.Command("sh", "-c", "printf %s "+shell_quote(arg)).Output()
+		if err != nil || string(output) != arg {
+			t.Errorf("shell_quote(%q) = %v, the shell gives %q, %v", arg, shell_quote(arg), output, err)
+		}
+	}
+}
@@ -0,0 +1,496 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Directory where theme files are searched, as <name>.toml
+const THEMES_DIR = "Themes"
+
+// ThemeColors contains the colors of the GUI as written in a theme file
+// or in the [Colors] section. An empty value means the color is not set.
+type ThemeColors struct {
+	Main         string `toml:",omitempty"`
+	Box          string `toml:",omitempty"`
+	TextArea     string `toml:",omitempty"`
+	FontActive   string `toml:",omitempty"`
+	FontInactive string `toml:",omitempty"`
+	FontMatch    string `toml:",omitempty"`
+	RowEven      string `toml:",omitempty"`
+	RowOdd       string `toml:",omitempty"`
+	RowSelected  string `toml:",omitempty"`
+	Header       string `toml:",omitempty"` // background of the section headers
+	FontHeader   string `toml:",omitempty"`
+}
+
+// Palette contains the colors used by the GUI, once parsed
+type Palette struct {
+	Main         rl.Color
+	Box          rl.Color
+	TextArea     rl.Color
+	FontActive   rl.Color
+	FontInactive rl.Color
+	FontMatch    rl.Color
+	RowEven      rl.Color
+	RowOdd       rl.Color
+	RowSelected  rl.Color
+	Header       rl.Color
+	FontHeader   rl.Color
+}
+
+// Names of the colors, in the same order as the fields() methods
+var color_names = []string{
+	"Main", "Box", "TextArea", "FontActive", "FontInactive",
+	"FontMatch", "RowEven", "RowOdd", "RowSelected", "Header", "FontHeader",
+}
+
+// Themes that are available without theme file
+var builtin_themes = map[string]ThemeColors{
+	"default": {
+		Main:         "SkyBlue",
+		Box:          "DarkGray",
+		TextArea:     "RayWhite",
+		FontActive:   "Black",
+		FontInactive: "Beige",
+		FontMatch:    "Red",
+		RowEven:      "LightGray",
+		RowOdd:       "Gray",
+		RowSelected:  "Green",
+		Header:       "DarkGray",
+		FontHeader:   "RayWhite",
+	},
+	"solarized-dark": {
+		Main:         "#073642",
+		Box:          "#586e75",
+		TextArea:     "#002b36",
+		FontActive:   "#93a1a1",
+		FontInactive: "#586e75",
+		FontMatch:    "#cb4b16",
+		RowEven:      "#002b36",
+		RowOdd:       "#073642",
+		RowSelected:  "#268bd2",
+		Header:       "#073642",
+		FontHeader:   "#b58900",
+	},
+	"solarized-light": {
+		Main:         "#eee8d5",
+		Box:          "#93a1a1",
+		TextArea:     "#fdf6e3",
+		FontActive:   "#586e75",
+		FontInactive: "#93a1a1",
+		FontMatch:    "#dc322f",
+		RowEven:      "#fdf6e3",
+		RowOdd:       "#eee8d5",
+		RowSelected:  "#2aa198",
+		Header:       "#eee8d5",
+		FontHeader:   "#b58900",
+	},
+	"dracula": {
+		Main:         "#44475a",
+		Box:          "#6272a4",
+		TextArea:     "#282a36",
+		FontActive:   "#f8f8f2",
+		FontInactive: "#6272a4",
+		FontMatch:    "#ff79c6",
+		RowEven:      "#282a36",
+		RowOdd:       "#21222c",
+		RowSelected:  "#6272a4",
+		Header:       "#44475a",
+		FontHeader:   "#bd93f9",
+	},
+	"nord": {
+		Main:         "#3b4252",
+		Box:          "#4c566a",
+		TextArea:     "#2e3440",
+		FontActive:   "#eceff4",
+		FontInactive: "#4c566a",
+		FontMatch:    "#88c0d0",
+		RowEven:      "#2e3440",
+		RowOdd:       "#3b4252",
+		RowSelected:  "#5e81ac",
+		Header:       "#434c5e",
+		FontHeader:   "#81a1c1",
+	},
+	"gruvbox-dark": {
+		Main:         "#3c3836",
+		Box:          "#504945",
+		TextArea:     "#282828",
+		FontActive:   "#ebdbb2",
+		FontInactive: "#928374",
+		FontMatch:    "#fe8019",
+		RowEven:      "#282828",
+		RowOdd:       "#32302f",
+		RowSelected:  "#689d6a",
+		Header:       "#3c3836",
+		FontHeader:   "#fabd2f",
+	},
+}
+
+func (t *ThemeColors) fields() []*string {
+	return []*string{
+		&t.Main, &t.Box, &t.TextArea, &t.FontActive, &t.FontInactive,
+		&t.FontMatch, &t.RowEven, &t.RowOdd, &t.RowSelected, &t.Header, &t.FontHeader,
+	}
+}
+
+func (p *Palette) fields() []*rl.Color {
+	return []*rl.Color{
+		&p.Main, &p.Box, &p.TextArea, &p.FontActive, &p.FontInactive,
+		&p.FontMatch, &p.RowEven, &p.RowOdd, &p.RowSelected, &p.Header, &p.FontHeader,
+	}
+}
+
+// Returns the theme colors where the colors set in other replace the current ones
+func (t ThemeColors) Merge(other ThemeColors) ThemeColors {
+	result := t
+	result_fields := result.fields()
+
+	for i, value := range other.fields() {
+		if *value != "" {
+			*result_fields[i] = *value
+		}
+	}
+
+	return result
+}
+
+// Loads a theme by name ("default" if empty).
+// A file named Themes/<name>.toml has priority over the built-in themes,
+// the name can also be the path of a .toml file.
+// Colors missing in a theme file will be the ones of the default theme.
+func LoadTheme(name string) (ThemeColors, error) {
+	var theme ThemeColors
+
+	if name == "" {
+		name = "default"
+	}
+
+	path := name
+	if !strings.HasSuffix(strings.ToLower(path), ".toml") {
+		path = filepath.Join(THEMES_DIR, name+".toml")
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		data, err := toml.DecodeFile(path, &theme)
+		if err != nil {
+			return theme, fmt.Errorf("theme %v: %w", name, err)
+		}
+
+		if undecoded := data.Undecoded(); len(undecoded) != 0 {
+			return theme, fmt.Errorf("theme %v: invalid keys %v", name, undecoded)
+		}
+
+		return theme, nil
+	}
+
+	theme, ok := builtin_themes[strings.ToLower(name)]
+	if !ok {
+		return theme, fmt.Errorf("unknown theme '%v'", name)
+	}
+
+	return theme, nil
+}
+
+// Parses the theme colors. Colors that are not set or invalid
+// are replaced by the ones of the default theme.
+func NewPalette(colors ThemeColors) Palette {
+	var palette Palette
+
+	defaults := builtin_themes["default"]
+	default_fields := defaults.fields()
+	palette_fields := palette.fields()
+
+	for i, value := range colors.fields() {
+		if *value != "" {
+			col, err := parse_color(*value)
+			if err == nil {
+				*palette_fields[i] = col
+				continue
+			}
+
+			log.Printf("%v: invalid color (%v) using default color %v", color_names[i], *value, *default_fields[i])
+		}
+
+		col, err := parse_color(*default_fields[i])
+		if err != nil {
+			log.Panic(err)
+		}
+		*palette_fields[i] = col
+	}
+
+	return palette
+}
+
+// Parses a color, that can be written as:
+//   - a raylib color name: "SkyBlue"
+//   - hexadecimal: "#rgb", "#rgba", "#rrggbb", "#rrggbbaa", or "rrggbb", "rrggbbaa" without #
+//   - CSS functions: "rgb(255, 0, 0)", "rgba(100%, 0%, 0%, 0.5)", "rgb(255 0 0 / 50%)",
+//     "hsl(120, 100%, 50%)", "hsla(120deg 100% 50% / 0.5)"
+func parse_color(in string) (rl.Color, error) {
+	in = strings.ToLower(strings.TrimSpace(in))
+
+	// check if the input color is the name of a known color
+	if col, err := str_to_color(in); err == nil {
+		return col, nil
+	}
+
+	switch {
+	case strings.HasPrefix(in, "#"):
+		return parse_hex_color(in[1:], true)
+	case strings.HasPrefix(in, "rgb"):
+		return parse_rgb_color(in)
+	case strings.HasPrefix(in, "hsl"):
+		return parse_hsl_color(in)
+	default:
+		// without #, the short forms are not allowed
+		return parse_hex_color(in, false)
+	}
+}
+
+func parse_hex_color(in string, allow_short bool) (rl.Color, error) {
+	value, err := strconv.ParseUint(in, 16, 32)
+	if err != nil || strings.HasPrefix(in, "+") {
+		return rl.Blank, fmt.Errorf("invalid hexadecimal color '%v'", in)
+	}
+
+	switch {
+	case len(in) == 3 && allow_short: // rgb
+		return rl.NewColor(uint8(value>>8&0xF)*0x11, uint8(value>>4&0xF)*0x11, uint8(value&0xF)*0x11, 255), nil
+	case len(in) == 4 && allow_short: // rgba
+		return rl.NewColor(uint8(value>>12&0xF)*0x11, uint8(value>>8&0xF)*0x11, uint8(value>>4&0xF)*0x11, uint8(value&0xF)*0x11), nil
+	case len(in) == 6: // rrggbb
+		return rl.NewColor(uint8(value>>16), uint8(value>>8), uint8(value), 255), nil
+	case len(in) == 8: // rrggbbaa
+		return rl.NewColor(uint8(value>>24), uint8(value>>16), uint8(value>>8), uint8(value)), nil
+	default:
+		return rl.Blank, fmt.Errorf("invalid hexadecimal color length '%v'", in)
+	}
+}
+
+func parse_rgb_color(in string) (rl.Color, error) {
+	args, err := parse_color_function(in, "rgb")
+	if err != nil {
+		return rl.Blank, err
+	}
+
+	var channels [3]uint8
+	for i := range channels {
+		// either 0 to 255 or a percentage
+		value, err := parse_color_number(args[i], 255)
+		if err != nil {
+			return rl.Blank, err
+		}
+		channels[i] = to_channel(value)
+	}
+
+	alpha, err := parse_alpha(args)
+	if err != nil {
+		return rl.Blank, err
+	}
+
+	return rl.NewColor(channels[0], channels[1], channels[2], alpha), nil
+}
+
+func parse_hsl_color(in string) (rl.Color, error) {
+	args, err := parse_color_function(in, "hsl")
+	if err != nil {
+		return rl.Blank, err
+	}
+
+	hue, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+	if err != nil {
+		return rl.Blank, fmt.Errorf("invalid hue '%v'", args[0])
+	}
+
+	// saturation and lightness are percentages, % is optional
+	saturation, err := parse_color_number(strings.TrimSuffix(args[1], "%")+"%", 1)
+	if err != nil {
+		return rl.Blank, err
+	}
+	lightness, err := parse_color_number(strings.TrimSuffix(args[2], "%")+"%", 1)
+	if err != nil {
+		return rl.Blank, err
+	}
+
+	alpha, err := parse_alpha(args)
+	if err != nil {
+		return rl.Blank, err
+	}
+
+	r, g, b := hsl_to_rgb(hue, clamp(saturation, 0, 1), clamp(lightness, 0, 1))
+
+	return rl.NewColor(to_channel(r*255), to_channel(g*255), to_channel(b*255), alpha), nil
+}
+
+// Returns the arguments of a color function like "rgb(1, 2, 3)" or "rgba(1 2 3 / 0.5)",
+// there are always 3 or 4 arguments
+func parse_color_function(in string, name string) ([]string, error) {
+	open := strings.Index(in, "(")
+	if open == -1 || !strings.HasSuffix(in, ")") {
+		return nil, fmt.Errorf("invalid color '%v'", in)
+	}
+
+	// the function name can have an "a" (eg: rgba)
+	if function := strings.TrimSpace(in[:open]); function != name && function != name+"a" {
+		return nil, fmt.Errorf("invalid color function '%v'", function)
+	}
+
+	// arguments are separated by commas or spaces, and the alpha can be after a /
+	args := strings.Fields(strings.NewReplacer(",", " ", "/", " ").Replace(in[open+1 : len(in)-1]))
+
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("invalid number of values in color '%v'", in)
+	}
+
+	return args, nil
+}
+
+// Parses the alpha value of a color function (4th argument), 255 if there is none
+func parse_alpha(args []string) (uint8, error) {
+	if len(args) < 4 {
+		return 255, nil
+	}
+
+	// either 0 to 1 or a percentage
+	value, err := parse_color_number(args[3], 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return to_channel(clamp(value, 0, 1) * 255), nil
+}
+
+// Parses a number or a percentage, a percentage is relative to max
+func parse_color_number(in string, max float64) (float64, error) {
+	if percent, ok := strings.CutSuffix(in, "%"); ok {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage '%v'", in)
+		}
+		return value / 100 * max, nil
+	}
+
+	value, err := strconv.ParseFloat(in, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%v'", in)
+	}
+
+	return value, nil
+}
+
+// Converts a HSL color to RGB, with all values between 0 and 1 except the hue in degrees
+func hsl_to_rgb(hue float64, saturation float64, lightness float64) (float64, float64, float64) {
+	hue = math.Mod(math.Mod(hue, 360)+360, 360) / 360
+
+	if saturation == 0 {
+		return lightness, lightness, lightness
+	}
+
+	var q float64
+	if lightness < 0.5 {
+		q = lightness * (1 + saturation)
+	} else {
+		q = lightness + saturation - lightness*saturation
+	}
+	p := 2*lightness - q
+
+	return hue_to_rgb(p, q, hue+1.0/3), hue_to_rgb(p, q, hue), hue_to_rgb(p, q, hue-1.0/3)
+}
+
+func hue_to_rgb(p float64, q float64, t float64) float64 {
+	if t < 0 {
+		t += 1
+	}
+	if t > 1 {
+		t -= 1
+	}
+
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	default:
+		return p
+	}
+}
+
+// Converts a value between 0 and 255 to a color channel (rounded and clamped)
+func to_channel(value float64) uint8 {
+	return uint8(math.Round(clamp(value, 0, 255)))
+}
+
+func clamp(value float64, low float64, high float64) float64 {
+	return math.Max(low, math.Min(value, high))
+}
+
+func str_to_color(in string) (rl.Color, error) {
+	switch strings.ToLower(in) {
+	case "beige":
+		return rl.Beige, nil
+	case "black":
+		return rl.Black, nil
+	case "blank":
+		return rl.Blank, nil
+	case "blue":
+		return rl.Blue, nil
+	case "brown":
+		return rl.Brown, nil
+	case "darkblue":
+		return rl.DarkBlue, nil
+	case "darkbrown":
+		return rl.DarkBrown, nil
+	case "darkgray":
+		return rl.DarkGray, nil
+	case "darkgreen":
+		return rl.DarkGreen, nil
+	case "darkpurple":
+		return rl.DarkPurple, nil
+	case "gold":
+		return rl.Gold, nil
+	case "gray":
+		return rl.Gray, nil
+	case "green":
+		return rl.Green, nil
+	case "lightgray":
+		return rl.LightGray, nil
+	case "lime":
+		return rl.Lime, nil
+	case "magenta":
+		return rl.Magenta, nil
+	case "maroon":
+		return rl.Maroon, nil
+	case "orange":
+		return rl.Orange, nil
+	case "pink":
+		return rl.Pink, nil
+	case "purple":
+		return rl.Purple, nil
+	case "raywhite":
+		return rl.RayWhite, nil
+	case "red":
+		return rl.Red, nil
+	case "skyblue":
+		return rl.SkyBlue, nil
+	case "violet":
+		return rl.Violet, nil
+	case "white":
+		return rl.White, nil
+	case "yellow":
+		return rl.Yellow, nil
+	default:
+		return rl.Blank, errors.New("unknown color")
+	}
+}
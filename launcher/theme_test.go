@@ -0,0 +1,173 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestParseColor(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want rl.Color
+	}{
+		// names
+		{"SkyBlue", rl.SkyBlue},
+		{"skyblue", rl.SkyBlue},
+		{" RED ", rl.Red},
+		// hexadecimal without #, as in the previous versions
+		{"FF8000", rl.NewColor(255, 128, 0, 255)},
+		{"ff8000", rl.NewColor(255, 128, 0, 255)},
+		{"FF800080", rl.NewColor(255, 128, 0, 128)},
+		// hexadecimal with #
+		{"#f80", rl.NewColor(255, 136, 0, 255)},
+		{"#F808", rl.NewColor(255, 136, 0, 136)},
+		{"#ff8000", rl.NewColor(255, 128, 0, 255)},
+		{"#Ff8000", rl.NewColor(255, 128, 0, 255)},
+		{"#ff800080", rl.NewColor(255, 128, 0, 128)},
+		// rgb
+		{"rgb(255, 128, 0)", rl.NewColor(255, 128, 0, 255)},
+		{"rgb(255 128 0)", rl.NewColor(255, 128, 0, 255)},
+		{"RGB(255,128,0)", rl.NewColor(255, 128, 0, 255)},
+		{"rgb(100%, 50%, 0%)", rl.NewColor(255, 128, 0, 255)},
+		{"rgb(300, -5, 0)", rl.NewColor(255, 0, 0, 255)},
+		{"rgba(255, 128, 0, 0.5)", rl.NewColor(255, 128, 0, 128)},
+		{"rgba(255, 128, 0, 25%)", rl.NewColor(255, 128, 0, 64)},
+		{"rgb(255 128 0 / 0.5)", rl.NewColor(255, 128, 0, 128)},
+		{"rgb(255 128 0 / 100%)", rl.NewColor(255, 128, 0, 255)},
+		// hsl
+		{"hsl(0, 100%, 50%)", rl.NewColor(255, 0, 0, 255)},
+		{"hsl(120, 100%, 50%)", rl.NewColor(0, 255, 0, 255)},
+		{"hsl(240deg 100% 50%)", rl.NewColor(0, 0, 255, 255)},
+		{"hsl(-120, 100%, 50%)", rl.NewColor(0, 0, 255, 255)},
+		{"hsl(480, 100%, 50%)", rl.NewColor(0, 255, 0, 255)},
+		{"hsl(30, 100%, 50%)", rl.NewColor(255, 128, 0, 255)},
+		{"hsl(0, 0%, 50%)", rl.NewColor(128, 128, 128, 255)},
+		{"hsl(0 0 100)", rl.NewColor(255, 255, 255, 255)},
+		{"hsla(120, 100%, 25%, 0.5)", rl.NewColor(0, 128, 0, 128)},
+		{"hsl(120 100% 25% / 50%)", rl.NewColor(0, 128, 0, 128)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parse_color(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColorInvalid(t *testing.T) {
+	var tests = []string{
+		"",
+		"NotAColor",
+		"f80",    // short forms need a #
+		"#ff800", // invalid length
+		"#ff8000800",
+		"#gg8000",
+		"#+f8000",
+		"ff 8000",
+		"rgb(255, 128)",
+		"rgb(255, 128, 0, 1, 1)",
+		"rgb(255, 128, x)",
+		"rgb(255, 128, 0",
+		"rgb 255, 128, 0)",
+		"rgbx(255, 128, 0)",
+		"rgb(255, 128, 0, a)",
+		"hsl(x, 100%, 50%)",
+		"hsl(120, x%, 50%)",
+		"hsl(120, 100%)",
+	}
+
+	for _, in := range tests {
+		t.Run(in, func(t *testing.T) {
+			if got, err := parse_color(in); err == nil {
+				t.Errorf("expected an error, got %v", got)
+			}
+		})
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	// every built-in theme must be complete and valid
+	for name := range builtin_themes {
+		theme, err := LoadTheme(name)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", name, err)
+		}
+
+		for i, value := range theme.fields() {
+			if _, err := parse_color(*value); err != nil {
+				t.Errorf("%v: %v: %v", name, color_names[i], err)
+			}
+		}
+	}
+
+	// names are not case sensitive, and empty is the default theme
+	if theme, err := LoadTheme("Solarized-Dark"); err != nil || theme != builtin_themes["solarized-dark"] {
+		t.Errorf("got %v %v, want solarized-dark", theme, err)
+	}
+	if theme, err := LoadTheme(""); err != nil || theme != builtin_themes["default"] {
+		t.Errorf("got %v %v, want default", theme, err)
+	}
+
+	if _, err := LoadTheme("unknown"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom.toml")
+
+	err := os.WriteFile(path, []byte("Main = \"#102030\"\nFontMatch = \"Gold\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theme, err := LoadTheme(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if theme.Main != "#102030" || theme.FontMatch != "Gold" || theme.Box != "" {
+		t.Errorf("unexpected theme %+v", theme)
+	}
+
+	// unknown keys are not allowed
+	err = os.WriteFile(path, []byte("Main = \"#102030\"\nTypo = \"Gold\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTheme(path); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func TestPalette(t *testing.T) {
+	// the config colors replace the ones of the theme
+	theme := builtin_themes["nord"].Merge(ThemeColors{Main: "#ff0000", Box: "invalid"})
+
+	palette := NewPalette(theme)
+
+	if palette.Main != rl.NewColor(255, 0, 0, 255) {
+		t.Errorf("got Main %v, want the config color", palette.Main)
+	}
+	if palette.TextArea != rl.NewColor(0x2e, 0x34, 0x40, 255) {
+		t.Errorf("got TextArea %v, want the theme color", palette.TextArea)
+	}
+	// invalid colors are replaced by the default theme ones
+	if palette.Box != rl.DarkGray {
+		t.Errorf("got Box %v, want the default color", palette.Box)
+	}
+
+	// missing colors (from a partial theme file) too
+	palette = NewPalette(ThemeColors{RowOdd: "black"})
+	if palette.RowOdd != rl.Black || palette.RowEven != rl.LightGray {
+		t.Errorf("unexpected palette %+v", palette)
+	}
+}
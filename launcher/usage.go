@@ -0,0 +1,46 @@
+package launcher
+
+import (
+	"sync"
+	"time"
+)
+
+// Usage of a rule given by a provider, saved in the config
+type RuleUsage struct {
+	LastUse  time.Time
+	UseCount int
+}
+
+// UsageStore remembers the use of the rules given by the providers, so that
+// they are sorted like the rules of the config file
+type UsageStore struct {
+	lock sync.Mutex // the rules are made in the background
+	uses map[string]RuleUsage
+}
+
+// Returns a store updating the map of the config
+func NewUsageStore(uses *map[string]RuleUsage) *UsageStore {
+	if *uses == nil {
+		*uses = map[string]RuleUsage{}
+	}
+
+	return &UsageStore{uses: *uses}
+}
+
+// Sets the usage of the rule, and updates it when the rule is executed.
+// The key identifies the rule, eg: its section and Match.
+func (u *UsageStore) Track(rule *Rule, key string) {
+	u.lock.Lock()
+	use := u.uses[key]
+	u.lock.Unlock()
+
+	rule.LastUse = use.LastUse
+	rule.UseCount = use.UseCount
+
+	rule.used = func() {
+		u.lock.Lock()
+		defer u.lock.Unlock()
+
+		u.uses[key] = RuleUsage{LastUse: rule.LastUse, UseCount: rule.UseCount}
+	}
+}
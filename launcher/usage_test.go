@@ -0,0 +1,38 @@
+package launcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageStore(t *testing.T) {
+	var uses map[string]RuleUsage
+	usage := NewUsageStore(&uses)
+
+	// a rule never used
+	rule := &Rule{Match: "host", Description: "ssh host", execute: func() {}}
+	usage.Track(rule, "SSH:host")
+	if !rule.LastUse.IsZero() || rule.UseCount != 0 {
+		t.Errorf("new rule: %v, %d", rule.LastUse, rule.UseCount)
+	}
+
+	rule.Execute()
+	rule.Execute()
+
+	// the use is saved in the map of the config, and given to the next rules
+	if uses["SSH:host"].UseCount != 2 || uses["SSH:host"].LastUse != rule.LastUse {
+		t.Errorf("saved usage = %+v", uses["SSH:host"])
+	}
+
+	again := &Rule{Match: "host", Description: "ssh host"}
+	usage.Track(again, "SSH:host")
+	if again.UseCount != 2 || time.Since(again.LastUse) > time.Minute {
+		t.Errorf("tracked rule: %v, %d", again.LastUse, again.UseCount)
+	}
+
+	other := &Rule{Match: "other", Description: "ssh other"}
+	usage.Track(other, "SSH:other")
+	if other.UseCount != 0 {
+		t.Errorf("other rule: UseCount = %d", other.UseCount)
+	}
+}
@@ -0,0 +1,189 @@
+package launcher
+
+// Viewport manages the selected element of a list and the part
+// of the list that is displayed. The selection is always kept visible.
+type Viewport struct {
+	Count    int                    // number of elements in the list
+	Size     int                    // number of elements that can be displayed
+	Margin   int                    // number of elements kept visible before/after the selection
+	Wrap     bool                   // going after the last element selects the first one (and vice versa)
+	First    int                    // first element displayed
+	Current  int                    // selected element, -1 if there is none (the typing field is active)
+	Disabled func(element int) bool // elements that can not be selected (eg: headers), nil if there are none
+}
+
+func NewViewport(size int, margin int, wrap bool) *Viewport {
+	return &Viewport{
+		Size:    size,
+		Margin:  margin,
+		Wrap:    wrap,
+		Current: -1,
+	}
+}
+
+// Sets the number of elements of the list and goes back to the top, with no selection
+func (v *Viewport) Reset(count int) {
+	v.Count = count
+	v.First = 0
+	v.Current = -1
+}
+
+// Returns the last element displayed, or -1 if the list is empty
+func (v *Viewport) Last() int {
+	return min(v.First+v.Size, v.Count) - 1
+}
+
+// Returns the element to use when validating: the selected one, or the
+// first one if none is selected. Returns -1 if nothing can be selected.
+func (v *Viewport) Target() int {
+	if v.Current != -1 {
+		return v.Current
+	}
+
+	return v.find(0, 1)
+}
+
+// Returns true if the element exists and can be selected
+func (v *Viewport) IsSelectable(element int) bool {
+	return element >= 0 && element < v.Count && (v.Disabled == nil || !v.Disabled(element))
+}
+
+// Returns true if the list is too long to be displayed at once
+func (v *Viewport) IsScrollable() bool {
+	return v.Count > v.Size
+}
+
+func (v *Viewport) Next() {
+	if v.Count == 0 {
+		return
+	}
+
+	if next := v.find(v.Current+1, 1); next != -1 {
+		v.Select(next)
+	} else if first := v.find(0, 1); v.Wrap && first != -1 {
+		v.Select(first)
+	} else {
+		v.Select(v.Current) // it may have been hidden by scrolling
+	}
+}
+
+func (v *Viewport) Prev() {
+	if v.Count == 0 {
+		return
+	}
+
+	if prev := v.find(v.Current-1, -1); prev != -1 {
+		v.Select(prev)
+	} else if last := v.find(v.Count-1, -1); v.Wrap && last != -1 {
+		v.Select(last)
+	} else {
+		v.Select(v.Current) // it may have been hidden by scrolling
+	}
+}
+
+// Goes down by a page, without wrapping
+func (v *Viewport) PageDown() {
+	if v.Count == 0 {
+		return
+	}
+
+	// the closest selectable element before the target, or after it
+	target := min(v.Current+v.Size, v.Count-1)
+	element := v.find(target, -1)
+	if element <= v.Current {
+		element = v.find(target, 1)
+	}
+	if element == -1 {
+		element = v.Current
+	}
+
+	v.Select(element)
+}
+
+// Goes up by a page, without wrapping
+func (v *Viewport) PageUp() {
+	if v.Count == 0 {
+		return
+	}
+
+	// the closest selectable element after the target, or before it
+	target := max(v.Current-v.Size, 0)
+	element := v.find(target, 1)
+	if element == -1 || (v.Current != -1 && element >= v.Current) {
+		element = v.find(target, -1)
+	}
+	if element == -1 {
+		element = v.Current
+	}
+
+	v.Select(element)
+}
+
+func (v *Viewport) Home() {
+	if first := v.find(0, 1); first != -1 {
+		v.Select(first)
+	}
+}
+
+func (v *Viewport) End() {
+	if last := v.find(v.Count-1, -1); last != -1 {
+		v.Select(last)
+	}
+}
+
+// Selects an element (-1 to select none) and scrolls to keep it visible
+// with its margin. The margin is not applied at the ends of the list.
+func (v *Viewport) Select(element int) {
+	v.Current = max(-1, min(element, v.Count-1))
+
+	if v.Current == -1 {
+		return
+	}
+
+	// the margin can not be more than half of the displayed elements
+	margin := max(0, min(v.Margin, (v.Size-1)/2))
+
+	if v.Current-margin < v.First {
+		v.First = v.Current - margin
+	}
+	if v.Current+margin > v.First+v.Size-1 {
+		v.First = v.Current + margin - v.Size + 1
+	}
+
+	// show the elements that can not be selected before the first one (eg: a header)
+	if v.find(v.Current-1, -1) == -1 && v.Current < v.Size {
+		v.First = 0
+	}
+
+	v.clamp()
+}
+
+// Moves the displayed part of the list, without changing the selection
+// (that can become hidden)
+func (v *Viewport) Scroll(delta int) {
+	v.ScrollTo(v.First + delta)
+}
+
+// Displays the list from the given element, without changing the selection
+func (v *Viewport) ScrollTo(first int) {
+	v.First = first
+	v.clamp()
+}
+
+// Makes sure the first element displayed is valid,
+// and that the display is filled if there are enough elements
+func (v *Viewport) clamp() {
+	v.First = max(0, min(v.First, v.Count-v.Size))
+}
+
+// Returns the first selectable element from start, going in the direction (1 or -1).
+// Returns -1 if there is none.
+func (v *Viewport) find(start int, direction int) int {
+	for i := start; i >= 0 && i < v.Count; i += direction {
+		if v.IsSelectable(i) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,328 @@
+package launcher
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Operations that can be done on a viewport, by name
+var viewport_ops = map[string]func(v *Viewport){
+	"next":      (*Viewport).Next,
+	"prev":      (*Viewport).Prev,
+	"page-down": (*Viewport).PageDown,
+	"page-up":   (*Viewport).PageUp,
+	"home":      (*Viewport).Home,
+	"end":       (*Viewport).End,
+	"scroll+1":  func(v *Viewport) { v.Scroll(1) },
+	"scroll-3":  func(v *Viewport) { v.Scroll(-3) },
+}
+
+// Checks the invariants that must be true after any operation
+func check_viewport(t *testing.T, v *Viewport, context string) {
+	t.Helper()
+
+	if v.First < 0 || v.First > max(0, v.Count-v.Size) {
+		t.Fatalf("%v: invalid first %d (%+v)", context, v.First, *v)
+	}
+	if v.Current < -1 || v.Current >= v.Count {
+		t.Fatalf("%v: invalid current %d (%+v)", context, v.Current, *v)
+	}
+	if v.Count > 0 && v.Last() != min(v.First+v.Size, v.Count)-1 {
+		t.Fatalf("%v: invalid last %d (%+v)", context, v.Last(), *v)
+	}
+	if v.Count == 0 && (v.Last() != -1 || v.Current != -1 || v.Target() != -1) {
+		t.Fatalf("%v: empty list should have no element (%+v)", context, *v)
+	}
+}
+
+// Checks that the selection is visible, with its margin when possible
+func check_visible(t *testing.T, v *Viewport, context string) {
+	t.Helper()
+
+	if v.Current == -1 {
+		return
+	}
+
+	if v.Current < v.First || v.Current > v.Last() {
+		t.Fatalf("%v: selection is not visible (%+v)", context, *v)
+	}
+
+	margin := min(v.Margin, (v.Size-1)/2)
+	if v.Current-v.First < margin && v.First > 0 {
+		t.Fatalf("%v: margin before the selection is not respected (%+v)", context, *v)
+	}
+	if v.Last()-v.Current < margin && v.Last() < v.Count-1 {
+		t.Fatalf("%v: margin after the selection is not respected (%+v)", context, *v)
+	}
+}
+
+// Runs every sequence of 3 operations on many list configurations
+func TestViewportExhaustive(t *testing.T) {
+	const SIZE = 5
+
+	for count := 0; count <= 3*SIZE; count++ {
+		for margin := 0; margin <= SIZE; margin++ {
+			for _, wrap := range []bool{false, true} {
+				for name1, op1 := range viewport_ops {
+					for name2, op2 := range viewport_ops {
+						for name3, op3 := range viewport_ops {
+							v := NewViewport(SIZE, margin, wrap)
+							v.Reset(count)
+							check_viewport(t, v, "reset")
+
+							context := fmt.Sprintf("count=%d margin=%d wrap=%v %v,%v,%v", count, margin, wrap, name1, name2, name3)
+							for i, op := range []func(*Viewport){op1, op2, op3} {
+								op(v)
+								check_viewport(t, v, context)
+
+								// only the scrolling can hide the selection
+								if name := []string{name1, name2, name3}[i]; !strings.HasPrefix(name, "scroll") {
+									check_visible(t, v, context)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestViewportEmpty(t *testing.T) {
+	for _, wrap := range []bool{false, true} {
+		v := NewViewport(10, 1, wrap)
+		v.Reset(0)
+
+		for name, op := range viewport_ops {
+			op(v)
+			if v.Current != -1 || v.First != 0 {
+				t.Errorf("%v (wrap=%v): got current %d first %d, want -1 0", name, wrap, v.Current, v.First)
+			}
+		}
+
+		if v.IsScrollable() {
+			t.Error("empty list should not be scrollable")
+		}
+	}
+}
+
+// Expected (current, first) after each operation, starting with no selection
+type viewport_step struct {
+	op      string
+	current int
+	first   int
+}
+
+func run_viewport_steps(t *testing.T, v *Viewport, steps []viewport_step) {
+	t.Helper()
+
+	for i, step := range steps {
+		viewport_ops[step.op](v)
+
+		if v.Current != step.current || v.First != step.first {
+			t.Fatalf("step %d (%v): got current %d first %d, want %d %d",
+				i, step.op, v.Current, v.First, step.current, step.first)
+		}
+	}
+}
+
+func TestViewportSmallList(t *testing.T) {
+	// less elements than displayed, nothing ever scrolls
+	v := NewViewport(10, 1, false)
+	v.Reset(3)
+
+	if v.IsScrollable() || v.Last() != 2 || v.Target() != 0 {
+		t.Fatalf("unexpected state %+v", *v)
+	}
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"prev", -1, 0},
+		{"next", 0, 0},
+		{"prev", 0, 0},
+		{"next", 1, 0},
+		{"next", 2, 0},
+		{"next", 2, 0},
+		{"home", 0, 0},
+		{"page-down", 2, 0},
+		{"page-up", 0, 0},
+		{"end", 2, 0},
+		{"scroll+1", 2, 0},
+	})
+
+	// with wrapping
+	v = NewViewport(10, 1, true)
+	v.Reset(3)
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"prev", 2, 0},
+		{"next", 0, 0},
+		{"prev", 2, 0},
+		{"page-down", 2, 0},
+		{"page-up", 0, 0},
+		{"page-up", 0, 0},
+	})
+}
+
+func TestViewportExactSize(t *testing.T) {
+	// as many elements as displayed
+	v := NewViewport(4, 1, false)
+	v.Reset(4)
+
+	if v.IsScrollable() || v.Last() != 3 {
+		t.Fatalf("unexpected state %+v", *v)
+	}
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"next", 0, 0},
+		{"next", 1, 0},
+		{"next", 2, 0},
+		{"next", 3, 0},
+		{"next", 3, 0},
+		{"scroll+1", 3, 0},
+		{"page-up", 0, 0},
+		{"end", 3, 0},
+		{"home", 0, 0},
+	})
+
+	// one more element makes it scrollable
+	v.Reset(5)
+	if !v.IsScrollable() {
+		t.Fatal("list should be scrollable")
+	}
+}
+
+func TestViewportScrolling(t *testing.T) {
+	// 10 elements, 4 displayed, 1 element of margin
+	v := NewViewport(4, 1, true)
+	v.Reset(10)
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"next", 0, 0},
+		{"next", 1, 0},
+		{"next", 2, 0},
+		{"next", 3, 1}, // 4 is kept visible after 3
+		{"next", 4, 2},
+		{"prev", 3, 2},
+		{"prev", 2, 1}, // 1 is kept visible before 2
+		{"prev", 1, 0},
+		{"prev", 0, 0},
+		{"prev", 9, 6}, // wrap to the end
+		{"next", 0, 0}, // and back to the start
+		{"page-down", 4, 2},
+		{"page-down", 8, 6},
+		{"page-down", 9, 6},
+		{"page-up", 5, 4},
+		{"page-up", 1, 0},
+		{"end", 9, 6},
+		{"scroll-3", 9, 3}, // the selection can be hidden by scrolling
+		{"scroll-3", 9, 0},
+		{"scroll-3", 9, 0},
+		{"next", 0, 0},
+		{"scroll+1", 0, 1},
+		{"next", 1, 0}, // selecting shows the selection back
+	})
+
+	// page down without selection goes to the bottom of the first page
+	v.Reset(10)
+	run_viewport_steps(t, v, []viewport_step{
+		{"page-down", 3, 1},
+	})
+}
+
+func TestViewportMargin(t *testing.T) {
+	// a margin too big is limited to half of the displayed elements
+	v := NewViewport(5, 100, false)
+	v.Reset(20)
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"next", 0, 0},
+		{"next", 1, 0},
+		{"next", 2, 0},
+		{"next", 3, 1},
+		{"end", 19, 15},
+		{"prev", 18, 15},
+		{"prev", 17, 15},
+		{"prev", 16, 14},
+	})
+
+	// no margin, the list scrolls when the selection goes out of it
+	v = NewViewport(3, 0, false)
+	v.Reset(5)
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"next", 0, 0},
+		{"next", 1, 0},
+		{"next", 2, 0},
+		{"next", 3, 1},
+		{"prev", 2, 1},
+		{"prev", 1, 1},
+		{"prev", 0, 0},
+	})
+}
+
+func TestViewportSelect(t *testing.T) {
+	v := NewViewport(4, 0, false)
+	v.Reset(10)
+
+	v.Select(7)
+	if v.Current != 7 || v.First != 4 {
+		t.Errorf("got current %d first %d, want 7 4", v.Current, v.First)
+	}
+
+	// out of range values are limited
+	v.Select(100)
+	if v.Current != 9 || v.First != 6 {
+		t.Errorf("got current %d first %d, want 9 6", v.Current, v.First)
+	}
+	v.Select(-5)
+	if v.Current != -1 || v.First != 6 || v.Target() != 0 {
+		t.Errorf("got current %d first %d, want -1 6", v.Current, v.First)
+	}
+
+	// the list shrinks
+	v.Reset(2)
+	if v.Current != -1 || v.First != 0 || v.Last() != 1 {
+		t.Errorf("got current %d first %d, want -1 0", v.Current, v.First)
+	}
+}
+
+func TestViewportDisabled(t *testing.T) {
+	// headers at 0, 3 and 4 (two headers in a row), 8 elements
+	headers := map[int]bool{0: true, 3: true, 4: true}
+
+	v := NewViewport(3, 0, true)
+	v.Disabled = func(element int) bool { return headers[element] }
+	v.Reset(8)
+
+	if v.Target() != 1 {
+		t.Errorf("got target %d, want 1", v.Target())
+	}
+
+	run_viewport_steps(t, v, []viewport_step{
+		{"next", 1, 0},
+		{"next", 2, 0},
+		{"next", 5, 3},
+		{"prev", 2, 2},
+		{"prev", 1, 0}, // the header before the first element is shown
+		{"prev", 7, 5}, // wrap
+		{"next", 1, 0},
+		{"page-down", 2, 0},
+		{"page-down", 5, 3},
+		{"page-up", 2, 2},
+		{"page-up", 1, 0},
+		{"end", 7, 5},
+		{"home", 1, 0},
+	})
+
+	// nothing can be selected
+	v.Disabled = func(element int) bool { return true }
+	v.Reset(3)
+
+	for _, op := range []string{"next", "prev", "page-down", "page-up", "home", "end"} {
+		viewport_ops[op](v)
+		if v.Current != -1 || v.Target() != -1 {
+			t.Errorf("%v: got current %d target %d, want -1 -1", op, v.Current, v.Target())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package launcher
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Vertical positions of the window on the monitor
+const (
+	ANCHOR_CENTER    = "center"
+	ANCHOR_TOP_THIRD = "top-third"
+)
+
+// Monitors that can be chosen by name, the others are chosen by index
+const (
+	MONITOR_PRIMARY = -1
+	MONITOR_MOUSE   = -2
+)
+
+// Monitor is the area of a monitor on the desktop, in pixels
+type Monitor struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Returns true if the point is on the monitor
+func (m Monitor) Contains(x int, y int) bool {
+	return x >= m.X && x < m.X+m.Width && y >= m.Y && y < m.Y+m.Height
+}
+
+// WindowLayout gives the size and position of the window, parsed from the Window section
+type WindowLayout struct {
+	Width   int     // width in pixels, if Percent is 0
+	Percent float64 // width in percentage of the monitor width
+	Anchor  string  // ANCHOR_CENTER or ANCHOR_TOP_THIRD
+	Monitor int     // index of the monitor, MONITOR_PRIMARY or MONITOR_MOUSE
+	Shrink  bool    // the window height follows the number of displayed rows
+}
+
+// Parses the window settings:
+//   - width: pixels ("600" or "600px") or percentage of the monitor ("40%")
+//   - anchor: "center" or "top-third"
+//   - monitor: "primary", "mouse" or the index of the monitor ("0" is the primary one)
+func NewWindowLayout(width string, anchor string, monitor string, shrink bool) (WindowLayout, error) {
+	layout := WindowLayout{Shrink: shrink}
+
+	width = strings.ToLower(strings.TrimSpace(width))
+	if percent, ok := strings.CutSuffix(width, "%"); ok {
+		value, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || value <= 0 || value > 100 {
+			return layout, fmt.Errorf("invalid width '%v', the percentage must be between 0 and 100", width)
+		}
+		layout.Percent = value
+	} else {
+		value, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(width, "px")))
+		if err != nil || value <= 0 {
+			return layout, fmt.Errorf("invalid width '%v'", width)
+		}
+		layout.Width = value
+	}
+
+	switch anchor = strings.ToLower(strings.TrimSpace(anchor)); anchor {
+	case ANCHOR_CENTER, ANCHOR_TOP_THIRD:
+		layout.Anchor = anchor
+	default:
+		return layout, fmt.Errorf("invalid anchor '%v', it must be %v or %v", anchor, ANCHOR_CENTER, ANCHOR_TOP_THIRD)
+	}
+
+	switch monitor = strings.ToLower(strings.TrimSpace(monitor)); monitor {
+	case "primary":
+		layout.Monitor = MONITOR_PRIMARY
+	case "mouse":
+		layout.Monitor = MONITOR_MOUSE
+	default:
+		value, err := strconv.Atoi(monitor)
+		if err != nil || value < 0 {
+			return layout, fmt.Errorf("invalid monitor '%v', it must be primary, mouse or an index", monitor)
+		}
+		layout.Monitor = value
+	}
+
+	return layout, nil
+}
+
+// Returns the index of the monitor to use. With MONITOR_MOUSE, if the mouse
+// position is unknown (has_mouse is false), the current monitor of the window
+// is used. The primary monitor (0) is used when the chosen one does not exist.
+func (l WindowLayout) SelectMonitor(monitors []Monitor, current int, mouse_x int, mouse_y int, has_mouse bool) int {
+	switch {
+	case l.Monitor == MONITOR_MOUSE && has_mouse:
+		for i, monitor := range monitors {
+			if monitor.Contains(mouse_x, mouse_y) {
+				return i
+			}
+		}
+	case l.Monitor == MONITOR_MOUSE && current >= 0 && current < len(monitors):
+		return current
+	case l.Monitor >= 0 && l.Monitor < len(monitors):
+		return l.Monitor
+	case l.Monitor >= 0:
+		log.Printf("monitor %d not found, using the primary monitor", l.Monitor)
+	}
+
+	return 0
+}
+
+// Returns the width of the window on the monitor, it can not be larger than the monitor.
+// A width in pixels is multiplied by the scale of the monitor.
+func (l WindowLayout) WindowWidth(monitor Monitor, scale float32) int {
+	width := int(scale_size(int32(l.Width), scale))
+	if l.Percent != 0 {
+		width = int(float64(monitor.Width) * l.Percent / 100)
+	}
+
+	if monitor.Width > 0 {
+		width = min(width, monitor.Width)
+	}
+
+	return width
+}
+
+// Returns the position of the top left corner of a window of the given size.
+// The height is the largest one of the window, so that the top of the window
+// does not move when it shrinks.
+func (l WindowLayout) WindowPosition(monitor Monitor, width int, height int) (int, int) {
+	x := monitor.X + (monitor.Width-width)/2
+
+	var y int
+	switch l.Anchor {
+	case ANCHOR_TOP_THIRD:
+		y = monitor.Y + monitor.Height/3 - height/2
+	default:
+		y = monitor.Y + (monitor.Height-height)/2
+	}
+
+	// keep the window on the monitor
+	y = max(monitor.Y, min(y, monitor.Y+monitor.Height-height))
+
+	return x, y
+}
+
+// Returns the size multiplied by the scale (rounded), the scale is ignored if it is not positive
+func scale_size(size int32, scale float32) int32 {
+	if scale <= 0 {
+		return size
+	}
+
+	return int32(float32(size)*scale + 0.5)
+}
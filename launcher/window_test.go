@@ -0,0 +1,154 @@
+package launcher
+
+import (
+	"testing"
+)
+
+func TestNewWindowLayout(t *testing.T) {
+	var tests = []struct {
+		width   string
+		anchor  string
+		monitor string
+		want    WindowLayout
+	}{
+		{"600", "center", "primary", WindowLayout{Width: 600, Anchor: ANCHOR_CENTER, Monitor: MONITOR_PRIMARY}},
+		{"800px", "Top-Third", "mouse", WindowLayout{Width: 800, Anchor: ANCHOR_TOP_THIRD, Monitor: MONITOR_MOUSE}},
+		{"40%", "center", "1", WindowLayout{Percent: 40, Anchor: ANCHOR_CENTER, Monitor: 1}},
+		{" 12.5 % ", "center", "0", WindowLayout{Percent: 12.5, Anchor: ANCHOR_CENTER, Monitor: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.width, func(t *testing.T) {
+			got, err := NewWindowLayout(tt.width, tt.anchor, tt.monitor, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWindowLayoutInvalid(t *testing.T) {
+	var tests = []struct {
+		width   string
+		anchor  string
+		monitor string
+	}{
+		{"", "center", "primary"},
+		{"0", "center", "primary"},
+		{"-600", "center", "primary"},
+		{"wide", "center", "primary"},
+		{"0%", "center", "primary"},
+		{"150%", "center", "primary"},
+		{"600", "bottom", "primary"},
+		{"600", "center", "secondary"},
+		{"600", "center", "-1"},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewWindowLayout(tt.width, tt.anchor, tt.monitor, false); err == nil {
+			t.Errorf("%v: expected an error", tt)
+		}
+	}
+}
+
+func TestWindowSelectMonitor(t *testing.T) {
+	// two monitors side by side, the second one is smaller
+	monitors := []Monitor{{0, 0, 1920, 1080}, {1920, 200, 1280, 720}}
+
+	var tests = []struct {
+		name      string
+		monitor   int
+		current   int
+		mouse_x   int
+		mouse_y   int
+		has_mouse bool
+		want      int
+	}{
+		{"primary", MONITOR_PRIMARY, 1, 2000, 300, true, 0},
+		{"index", 1, 0, 0, 0, true, 1},
+		{"missing index", 2, 1, 0, 0, true, 0},
+		{"mouse on first", MONITOR_MOUSE, 1, 100, 100, true, 0},
+		{"mouse on second", MONITOR_MOUSE, 0, 2000, 300, true, 1},
+		{"mouse outside", MONITOR_MOUSE, 1, 2000, 100, true, 0},
+		{"mouse unknown", MONITOR_MOUSE, 1, 0, 0, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := WindowLayout{Monitor: tt.monitor}
+
+			got := layout.SelectMonitor(monitors, tt.current, tt.mouse_x, tt.mouse_y, tt.has_mouse)
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowGeometry(t *testing.T) {
+	monitor := Monitor{1920, 200, 1200, 900}
+
+	var tests = []struct {
+		name   string
+		layout WindowLayout
+		height int
+		want_w int
+		want_x int
+		want_y int
+	}{
+		{"pixels centered", WindowLayout{Width: 600, Anchor: ANCHOR_CENTER}, 300, 600, 2220, 500},
+		{"percent centered", WindowLayout{Percent: 50, Anchor: ANCHOR_CENTER}, 300, 600, 2220, 500},
+		{"top third", WindowLayout{Width: 600, Anchor: ANCHOR_TOP_THIRD}, 300, 600, 2220, 350},
+		{"top third, tall window", WindowLayout{Width: 600, Anchor: ANCHOR_TOP_THIRD}, 800, 600, 2220, 200},
+		{"wider than the monitor", WindowLayout{Width: 3000, Anchor: ANCHOR_CENTER}, 300, 1200, 1920, 500},
+		{"higher than the monitor", WindowLayout{Percent: 100, Anchor: ANCHOR_CENTER}, 1000, 1200, 1920, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width := tt.layout.WindowWidth(monitor, 1)
+			x, y := tt.layout.WindowPosition(monitor, width, tt.height)
+
+			if width != tt.want_w || x != tt.want_x || y != tt.want_y {
+				t.Errorf("got %d at (%d, %d), want %d at (%d, %d)", width, x, y, tt.want_w, tt.want_x, tt.want_y)
+			}
+		})
+	}
+}
+
+func TestWindowScale(t *testing.T) {
+	monitor := Monitor{0, 0, 3840, 2160}
+
+	if width := (WindowLayout{Width: 600}).WindowWidth(monitor, 2); width != 1200 {
+		t.Errorf("got width %d, want 1200", width)
+	}
+	if width := (WindowLayout{Width: 600}).WindowWidth(monitor, 1.25); width != 750 {
+		t.Errorf("got width %d, want 750", width)
+	}
+	// a percentage is not scaled
+	if width := (WindowLayout{Percent: 25}).WindowWidth(monitor, 2); width != 960 {
+		t.Errorf("got width %d, want 960", width)
+	}
+
+	var tests = []struct {
+		size  int32
+		scale float32
+		want  int32
+	}{
+		{22, 1, 22},
+		{22, 1.5, 33},
+		{22, 1.25, 28},
+		{66, 2, 132},
+		{22, 0, 22},
+		{22, -1, 22},
+	}
+
+	for _, tt := range tests {
+		if got := scale_size(tt.size, tt.scale); got != tt.want {
+			t.Errorf("scale_size(%d, %v): got %d, want %d", tt.size, tt.scale, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
@@ -13,6 +14,10 @@ const (
 )
 
 func main() {
+	// the clipboard daemon records the clipboard while the launcher is closed
+	daemon := flag.Bool("clipboard", false, "record the clipboard history in the background")
+	flag.Parse()
+
 	// Open log file
 	file, err := os.OpenFile(LOG_FILE, os.O_CREATE|os.O_APPEND, 0644)
 
@@ -30,6 +35,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// the daemon runs along the launcher, it must not write the config
+	if *daemon {
+		if err := launcher.ClipboardDaemon_Start(config); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// make sure to write it back at the end
 	defer config.Write(CONFIG_FILE)
 